@@ -0,0 +1,166 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LogFormat is a plain text format for access logs.
+type LogFormat int
+
+const (
+	// CommonLog is the Common Log Format used by Apache and nginx.
+	CommonLog LogFormat = iota
+	// CombinedLog is the Common Log Format followed by the request's Referer and
+	// User-Agent headers.
+	CombinedLog
+)
+
+// clfTime is the layout of timestamps in the Common Log Format.
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// entry describes a single request, once it has been served.
+type entry struct {
+	r        *http.Request
+	header   http.Header
+	start    time.Time
+	duration time.Duration
+	status   int
+	bytes    int64
+}
+
+// AccessLog returns middleware that writes a record to logger for every request
+// once it has been served. Records include the request's method, path, route
+// pattern and name, along with the response's status and size, the time taken
+// and the client's IP address. If the request has been assigned an ID by
+// AssignRequestID, it is included too. If logger is nil, slog.Default is used.
+//
+// Requests that result in a 5xx status are logged at the error level, and all
+// others at the info level.
+func AccessLog(logger *slog.Logger) Middleware {
+	return accessLog(func(e entry) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+
+		level := slog.LevelInfo
+		if e.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", e.r.Method),
+			slog.String("path", e.r.URL.Path),
+		}
+		if route, pattern := ServedRoute(e.r); route != nil {
+			attrs = append(attrs, slog.String("route", pattern))
+			if route.name != "" {
+				attrs = append(attrs, slog.String("name", route.name))
+			}
+		}
+		attrs = append(attrs,
+			slog.Int("status", e.status),
+			slog.Int64("bytes", e.bytes),
+			slog.Duration("duration", e.duration),
+			slog.String("remote_ip", remoteIP(e.r)),
+		)
+		if id := e.requestID(); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+
+		l.LogAttrs(context.Background(), level, "request", attrs...)
+	})
+}
+
+// AccessLogFormat returns middleware that writes a line to w for every request
+// once it has been served, in the given plain text format.
+func AccessLogFormat(w io.Writer, format LogFormat) Middleware {
+	return accessLog(func(e entry) {
+		user := "-"
+		if u, _, ok := e.r.BasicAuth(); ok && u != "" {
+			user = u
+		}
+
+		size := "-"
+		if e.bytes > 0 {
+			size = strconv.FormatInt(e.bytes, 10)
+		}
+
+		line := fmt.Sprintf("%s - %s [%s] %q %d %s",
+			remoteIP(e.r), user, e.start.Format(clfTime),
+			e.r.Method+" "+e.r.RequestURI+" "+e.r.Proto, e.status, size,
+		)
+		if format == CombinedLog {
+			line += fmt.Sprintf(" %q %q", e.r.Referer(), e.r.UserAgent())
+		}
+
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			ReportError(e.r, err)
+		}
+	})
+}
+
+// requestID returns the ID assigned to the request. If AssignRequestID runs
+// inside the access log middleware, the ID is only on the response header.
+func (e entry) requestID() string {
+	if id := RequestID(e.r); id != "" {
+		return id
+	}
+
+	return e.header.Get(RequestIDHeader)
+}
+
+// accessLog returns middleware that calls write with a description of every
+// request it serves. If the handler panics, the request is described as a 500,
+// which is the response the router recovers with.
+func accessLog(write func(entry)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := WrapResponseWriter(w)
+			start := time.Now()
+
+			defer func() {
+				v := recover()
+
+				status := rw.Status()
+				if v != nil && status == 0 {
+					status = http.StatusInternalServerError
+				} else if status == 0 {
+					status = http.StatusOK
+				}
+
+				write(entry{
+					r:        r,
+					header:   rw.Header(),
+					start:    start,
+					duration: time.Since(start),
+					status:   status,
+					bytes:    rw.BytesWritten(),
+				})
+
+				if v != nil {
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// remoteIP returns the IP address of the client that made the request.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,137 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignRequestID(t *testing.T) {
+	var seen string
+	r := router.New().Middleware(router.AssignRequestID())
+	r.Get("/", func(req *http.Request) string {
+		seen = router.RequestID(req)
+		return ""
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, rec.Header().Get(router.RequestIDHeader))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(router.RequestIDHeader, "upstream-id")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "upstream-id", seen)
+	assert.Equal(t, "upstream-id", rec.Header().Get(router.RequestIDHeader))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(router.RequestIDHeader, "bad id\twith spaces")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, seen, 32)
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := router.New().Middleware(router.AssignRequestID(), router.AccessLog(logger))
+	r.Get("users/{id}", func() string {
+		return "hello"
+	}).Name("users.show")
+
+	billing := router.New()
+	billing.Get("invoices/{id}", func() string {
+		return "invoice"
+	}).Name("invoices.show")
+	r.Mount("/tenants/{tenant}/billing", billing)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set(router.RequestIDHeader, "abc")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/users/42", record["path"])
+	assert.Equal(t, "/users/{id}", record["route"])
+	assert.Equal(t, "users.show", record["name"])
+	assert.Equal(t, float64(200), record["status"])
+	assert.Equal(t, float64(5), record["bytes"])
+	assert.Equal(t, "192.0.2.1", record["remote_ip"])
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Contains(t, record, "duration")
+
+	buf.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/tenants/acme/billing/invoices/7", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	record = nil
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "/tenants/{tenant}/billing/invoices/{id}", record["route"])
+	assert.Equal(t, "invoices.show", record["name"])
+}
+
+func TestAccessLogRecordsPanics(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := router.New().OnError(func(*http.Request, error) {}).Middleware(router.AccessLog(logger))
+	r.Get("/", func() string {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, float64(500), record["status"])
+}
+
+func TestAccessLogFormat(t *testing.T) {
+	var common, combined bytes.Buffer
+	r := router.New().Middleware(
+		router.AccessLogFormat(&common, router.CommonLog),
+		router.AccessLogFormat(&combined, router.CombinedLog),
+	)
+	r.Get("/", func() string {
+		return "hello"
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/?page=2", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "test/1.0")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := `^192\.0\.2\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+		`"GET /\?page=2 HTTP/1\.1" 200 5`
+	assert.Regexp(t, regexp.MustCompile(line+"\n$"), common.String())
+	extended := line + ` "https://example.com/" "test/1\.0"`
+	assert.Regexp(t, regexp.MustCompile(extended+"\n$"), combined.String())
+}
+
+func TestAccessLogFormatReportsWriteErrors(t *testing.T) {
+	var reported error
+	r := router.New().
+		OnError(func(_ *http.Request, err error) { reported = err }).
+		Middleware(router.AccessLogFormat(failingWriter{}, router.CommonLog))
+	r.Get("/", helloHandler)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.EqualError(t, reported, "connection reset")
+}
@@ -0,0 +1,118 @@
+// Package auth provides guards that authenticate requests to the router. Each
+// guard provides Middleware that puts the authenticated Principal on the
+// request's context. Guards are registered on the router by name, and required
+// by routes and groups with Route.Auth and Group.Auth:
+//
+//	r.Guard("api", auth.Bearer("api", verifyToken).Middleware())
+//	r.Get("users", listUsers).Auth("api")
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gostalt/router"
+)
+
+type contextKey struct{}
+
+// Principal is the user or client that a request was authenticated as.
+type Principal struct {
+	// ID identifies the principal, such as a user ID or an API client's name.
+	ID string
+	// Scheme is how the principal was authenticated: "basic", "bearer",
+	// "apikey" or "session".
+	Scheme string
+	// Roles and Scopes are checked by the Role and Scope policies.
+	Roles  []string
+	Scopes []string
+	// Data holds anything else about the principal, such as a user record.
+	Data interface{}
+}
+
+// Get returns the principal that the request was authenticated as, or nil if it
+// wasn't authenticated.
+func Get(r *http.Request) *Principal {
+	p, _ := r.Context().Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Guard authenticates requests.
+type Guard struct {
+	// Authenticate returns the principal for the request, or nil if the
+	// request doesn't have valid credentials. Errors result in a 500.
+	Authenticate func(r *http.Request) (*Principal, error)
+
+	// Challenge is the WWW-Authenticate header sent with 401 responses.
+	Challenge string
+
+	// Login is the name of the route that browsers are redirected to when they
+	// aren't authenticated. If it is empty, they receive a 401 like other
+	// clients.
+	Login string
+
+	// Unauthenticated writes the response to requests that aren't
+	// authenticated, replacing the 401 and the redirect to Login.
+	Unauthenticated http.Handler
+}
+
+// RedirectTo returns a copy of the guard that redirects browsers to the route
+// with the given name, rather than sending them a 401.
+func (g Guard) RedirectTo(login string) Guard {
+	g.Login = login
+	return g
+}
+
+// Middleware returns middleware that authenticates requests with the guard.
+// Requests that aren't authenticated are refused, and never reach the handler.
+func (g Guard) Middleware() router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := g.Authenticate(r)
+			if err != nil {
+				router.ServerError(w, r, fmt.Errorf("auth: %w", err))
+				return
+			}
+
+			if p == nil {
+				g.unauthenticated(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, p)))
+		})
+	}
+}
+
+func (g Guard) unauthenticated(w http.ResponseWriter, r *http.Request) {
+	if g.Unauthenticated != nil {
+		g.Unauthenticated.ServeHTTP(w, r)
+		return
+	}
+
+	if g.Login != "" && isBrowser(r) {
+		login, err := router.URLFor(r, g.Login, nil)
+		if err == nil {
+			http.Redirect(w, r, login, http.StatusFound)
+			return
+		}
+		router.ReportError(r, fmt.Errorf("auth: %w", err))
+	}
+
+	if g.Challenge != "" {
+		w.Header().Set("WWW-Authenticate", g.Challenge)
+	}
+	http.Error(w, "401 unauthorized", http.StatusUnauthorized)
+}
+
+// isBrowser determines whether a request was made by a browser navigating to a
+// page, rather than by a script or an API client.
+func isBrowser(r *http.Request) bool {
+	if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+		return false
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
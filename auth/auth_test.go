@@ -0,0 +1,171 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/gostalt/router/auth"
+	"github.com/gostalt/router/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+func whoami(req *http.Request) string {
+	p := auth.Get(req)
+	return p.Scheme + ":" + p.ID
+}
+
+func serve(r *router.Router, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBasic(t *testing.T) {
+	verify := func(req *http.Request, username, password string) (*auth.Principal, error) {
+		if username == "frank" && password == "secret" {
+			return &auth.Principal{ID: username}, nil
+		}
+		return nil, nil
+	}
+	r := router.New().Guard("admin", auth.Basic("admin area", verify).Middleware())
+	r.Get("admin", whoami).Auth("admin")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.SetBasicAuth("frank", "secret")
+	rec := serve(r, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "basic:frank", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.SetBasicAuth("frank", "wrong")
+	rec = serve(r, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Basic realm="admin area", charset="UTF-8"`, rec.Header().Get("WWW-Authenticate"))
+
+	rec = serve(r, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestBearerAndAPIKey(t *testing.T) {
+	verify := func(req *http.Request, token string) (*auth.Principal, error) {
+		switch token {
+		case "good":
+			return &auth.Principal{ID: "client"}, nil
+		case "broken":
+			return nil, errors.New("token store unavailable")
+		}
+		return nil, nil
+	}
+
+	var reported error
+	r := router.New().
+		Guard("api", auth.Bearer("api", verify).Middleware()).
+		Guard("key", auth.APIKey("X-API-Key", verify).Middleware()).
+		OnError(func(req *http.Request, err error) {
+			reported = err
+		})
+	r.Get("bearer", whoami).Auth("api")
+	r.Get("key", whoami).Auth("key")
+
+	req := httptest.NewRequest(http.MethodGet, "/bearer", nil)
+	req.Header.Set("Authorization", "Bearer good")
+	assert.Equal(t, "bearer:client", serve(r, req).Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/bearer", nil)
+	req.Header.Set("Authorization", "Basic good")
+	rec := serve(r, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="api"`, rec.Header().Get("WWW-Authenticate"))
+
+	req = httptest.NewRequest(http.MethodGet, "/bearer", nil)
+	req.Header.Set("Authorization", "Bearer broken")
+	rec = serve(r, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "500 internal server error", rec.Body.String())
+	assert.EqualError(t, reported, "auth: token store unavailable")
+
+	req = httptest.NewRequest(http.MethodGet, "/key", nil)
+	req.Header.Set("X-API-Key", "good")
+	assert.Equal(t, "apikey:client", serve(r, req).Body.String())
+
+	rec = serve(r, httptest.NewRequest(http.MethodGet, "/key", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `APIKey header="X-API-Key"`, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestSessionGuardRedirectsBrowsers(t *testing.T) {
+	store := sessions.NewMemoryStore()
+	r := router.New().Middleware(sessions.Middleware(sessions.Config{Store: store, Insecure: true}))
+	r.Guard("web", auth.Session("user", func(req *http.Request, id string) (*auth.Principal, error) {
+		return &auth.Principal{ID: id}, nil
+	}).RedirectTo("login").Middleware())
+
+	r.Get("login", func(req *http.Request) string {
+		sessions.Get(req).Set("user", "42")
+		return "logged in"
+	}).Name("login")
+	r.Group(
+		router.Get("dashboard", whoami),
+	).Auth("web")
+
+	browser := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	browser.Header.Set("Accept", "text/html,application/xhtml+xml")
+	rec := serve(r, browser)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/login", rec.Header().Get("Location"))
+
+	script := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	script.Header.Set("Accept", "text/html")
+	script.Header.Set("X-Requested-With", "XMLHttpRequest")
+	assert.Equal(t, http.StatusUnauthorized, serve(r, script).Code)
+
+	rec = serve(r, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		req.AddCookie(cookies[0])
+		assert.Equal(t, "session:42", serve(r, req).Body.String())
+	}
+}
+
+func TestRouteGuardOverridesGroup(t *testing.T) {
+	var order []string
+	trace := func(name string) router.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	allow := func(id string) auth.Guard {
+		return auth.Guard{Authenticate: func(req *http.Request) (*auth.Principal, error) {
+			order = append(order, "guard "+id)
+			return &auth.Principal{ID: id}, nil
+		}}
+	}
+
+	r := router.New().
+		Guard("web", allow("web").Middleware()).
+		Guard("api", allow("api").Middleware())
+	r.Group(
+		router.Get("page", whoami).Middleware(trace("route")),
+		router.Get("api", whoami).Auth("api"),
+	).Auth("web").Middleware(trace("group"))
+
+	assert.Equal(t, ":web", serve(r, httptest.NewRequest(http.MethodGet, "/page", nil)).Body.String())
+	assert.Equal(t, []string{"group", "guard web", "route"}, order)
+
+	assert.Equal(t, ":api", serve(r, httptest.NewRequest(http.MethodGet, "/api", nil)).Body.String())
+}
+
+func TestUnknownGuard(t *testing.T) {
+	r := router.New().OnError(func(*http.Request, error) {})
+	r.Get("secret", whoami).Auth("missing")
+
+	rec := serve(r, httptest.NewRequest(http.MethodGet, "/secret", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
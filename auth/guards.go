@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gostalt/router/sessions"
+)
+
+// Basic returns a guard that authenticates requests with HTTP Basic
+// authentication. verify returns the principal for a username and password, or
+// nil if they aren't valid.
+func Basic(
+	realm string, verify func(r *http.Request, username, password string) (*Principal, error),
+) Guard {
+	return Guard{
+		Authenticate: func(r *http.Request) (*Principal, error) {
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				return nil, nil
+			}
+
+			p, err := verify(r, username, password)
+			return authenticated("basic", p, err)
+		},
+		Challenge: "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`,
+	}
+}
+
+// Bearer returns a guard that authenticates requests with a bearer token in the
+// Authorization header. verify returns the principal for a token, or nil if it
+// isn't valid.
+func Bearer(realm string, verify func(r *http.Request, token string) (*Principal, error)) Guard {
+	return Guard{
+		Authenticate: func(r *http.Request) (*Principal, error) {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				return nil, nil
+			}
+
+			p, err := verify(r, strings.TrimSpace(token))
+			return authenticated("bearer", p, err)
+		},
+		Challenge: "Bearer realm=" + strconv.Quote(realm),
+	}
+}
+
+// APIKey returns a guard that authenticates requests with an API key sent in the
+// given header. verify returns the principal for a key, or nil if it isn't
+// valid.
+func APIKey(header string, verify func(r *http.Request, key string) (*Principal, error)) Guard {
+	return Guard{
+		Authenticate: func(r *http.Request) (*Principal, error) {
+			key := r.Header.Get(header)
+			if key == "" {
+				return nil, nil
+			}
+
+			p, err := verify(r, key)
+			return authenticated("apikey", p, err)
+		},
+		Challenge: "APIKey header=" + strconv.Quote(header),
+	}
+}
+
+// Session returns a guard that authenticates requests by the user ID stored in
+// their session under key, typically when the user logs in. lookup returns the
+// principal for a user ID, or nil if the user no longer exists. The sessions
+// middleware must run before the guard.
+func Session(key string, lookup func(r *http.Request, id string) (*Principal, error)) Guard {
+	return Guard{
+		Authenticate: func(r *http.Request) (*Principal, error) {
+			s := sessions.Get(r)
+			if s == nil {
+				return nil, nil
+			}
+
+			id := s.Get(key)
+			if id == "" {
+				return nil, nil
+			}
+
+			p, err := lookup(r, id)
+			return authenticated("session", p, err)
+		},
+	}
+}
+
+// authenticated sets the scheme of the principal returned by a verify function,
+// if it returned one.
+func authenticated(scheme string, p *Principal, err error) (*Principal, error) {
+	if p != nil && p.Scheme == "" {
+		p.Scheme = scheme
+	}
+
+	return p, err
+}
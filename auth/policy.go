@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gostalt/router"
+)
+
+// Policy returns a router.Policy that decides whether the authenticated
+// principal may perform an ability on a model. Requests that haven't been
+// authenticated are denied.
+func Policy(allow func(p *Principal, model interface{}) bool) router.Policy {
+	return func(r *http.Request, model interface{}) (bool, error) {
+		p := Get(r)
+		if p == nil {
+			return false, nil
+		}
+
+		return allow(p, model), nil
+	}
+}
+
+// Role returns a router.Policy that allows principals with the given role.
+func Role(role string) router.Policy {
+	return Policy(func(p *Principal, _ interface{}) bool {
+		return contains(p.Roles, role)
+	})
+}
+
+// Scope returns a router.Policy that allows principals granted the given scope,
+// such as by the token they authenticated with.
+func Scope(scope string) router.Policy {
+	return Policy(func(p *Principal, _ interface{}) bool {
+		return contains(p.Scopes, scope)
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
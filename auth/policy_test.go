@@ -0,0 +1,45 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/gostalt/router/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAndScopePolicies(t *testing.T) {
+	principals := map[string]*auth.Principal{
+		"admin":  {ID: "1", Roles: []string{"admin"}},
+		"reader": {ID: "2", Scopes: []string{"posts:read"}},
+	}
+
+	r := router.New().
+		Guard("api", auth.Bearer("api", func(req *http.Request, token string) (*auth.Principal, error) {
+			return principals[token], nil
+		}).Middleware()).
+		Policy("admin", auth.Role("admin")).
+		Policy("posts:read", auth.Scope("posts:read")).
+		Policy("posts.show", auth.Policy(func(p *auth.Principal, model interface{}) bool {
+			return p.ID == "1" || model.(string) != "draft"
+		}))
+
+	r.Group(router.Get("admin/users", whoami)).Auth("api").Can("admin")
+	r.Get("posts/{post}", whoami).Auth("api").Can("posts:read").Can("posts.show", "post")
+
+	request := func(path string, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return serve(r, req).Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("/admin/users", "admin"))
+	assert.Equal(t, http.StatusForbidden, request("/admin/users", "reader"))
+	assert.Equal(t, http.StatusUnauthorized, request("/admin/users", "nobody"))
+
+	assert.Equal(t, http.StatusOK, request("/posts/hello", "reader"))
+	assert.Equal(t, http.StatusForbidden, request("/posts/draft", "reader"))
+	assert.Equal(t, http.StatusForbidden, request("/posts/hello", "admin"))
+}
@@ -0,0 +1,400 @@
+package router
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bindSources are the struct tags Bind reads values from, in the order they are
+// applied. Later sources override earlier ones for the same field.
+var bindSources = []string{"path", "query", "form", "header"}
+
+// BindError is returned by Bind when values couldn't be converted to the types
+// of the fields they were bound to. It is an HTTPError with a 422 status, and
+// Fields holds the problems with each field, keyed by the name in its tag.
+type BindError struct {
+	Fields map[string][]string
+}
+
+func (e BindError) Error() string {
+	return "invalid request: " + describeFields(e.Fields)
+}
+
+func (e BindError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// FieldErrors returns the problems with each field.
+func (e BindError) FieldErrors() map[string][]string {
+	return e.Fields
+}
+
+// describeFields lists the problems with each field, ordered by field name.
+func describeFields(fields map[string][]string) string {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		problems = append(problems, name+": "+strings.Join(fields[name], ", "))
+	}
+
+	return strings.Join(problems, "; ")
+}
+
+func (e *BindError) add(field string, problem string) {
+	if e.Fields == nil {
+		e.Fields = map[string][]string{}
+	}
+	e.Fields[field] = append(e.Fields[field], problem)
+}
+
+// Bind fills the fields of the struct pointed to by dst from the request. The
+// source of each field is set with a struct tag:
+//
+//	ID     int      `path:"id"`        a route parameter
+//	Page   int      `query:"page"`     a query string value
+//	Name   string   `form:"name"`      a form value from the request body
+//	Tenant string   `header:"X-Tenant"`
+//	Email  string   `json:"email"`     the JSON request body
+//
+// Values are converted to strings, bools, numbers, slices of these, pointers to
+// these, and types implementing encoding.TextUnmarshaler. Conversion failures
+// are collected and returned as a BindError. Only fields with a json tag are
+// set from the body.
+func Bind(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("router: Bind expects a pointer to a struct, got %T", dst)
+	}
+
+	var bindErr BindError
+
+	if hasJSONBody(r) {
+		if err := bindJSON(r, v.Elem(), &bindErr); err != nil {
+			return err
+		}
+	}
+
+	if err := r.ParseForm(); err != nil {
+		bindErr.add("body", err.Error())
+	}
+
+	bindStruct(r, v.Elem(), &bindErr)
+
+	if len(bindErr.Fields) > 0 {
+		return bindErr
+	}
+
+	return nil
+}
+
+// hasJSONBody determines whether the request has a JSON body to decode.
+func hasJSONBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// bindJSON decodes the request's JSON body into the fields of v that have a
+// json tag. Decoding into the whole struct would also fill fields meant for
+// other sources, such as headers, from body keys matching their names.
+func bindJSON(r *http.Request, v reflect.Value, bindErr *BindError) error {
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return StatusError{Code: http.StatusRequestEntityTooLarge, Err: err}
+		}
+		bindErr.add("body", jsonProblem(err, "", "must be valid JSON"))
+		return nil
+	}
+
+	bindJSONFields(body, v, bindErr)
+	return nil
+}
+
+func bindJSONFields(body map[string]json.RawMessage, v reflect.Value, bindErr *BindError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		tag, ok := field.Tag.Lookup("json")
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !ok {
+			bindJSONFields(body, fv, bindErr)
+			continue
+		}
+
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		raw, ok := jsonValue(body, name)
+		if !ok {
+			continue
+		}
+
+		if err := json.Unmarshal(raw, fv.Addr().Interface()); err != nil {
+			bindErr.add("body", jsonProblem(err, name, name+" is not valid: "+err.Error()))
+		}
+	}
+}
+
+// jsonValue returns the body's value for the key, preferring an exact match but
+// otherwise matching case-insensitively, as encoding/json does.
+func jsonValue(body map[string]json.RawMessage, key string) (json.RawMessage, bool) {
+	if raw, ok := body[key]; ok {
+		return raw, true
+	}
+
+	for k, raw := range body {
+		if strings.EqualFold(k, key) {
+			return raw, true
+		}
+	}
+
+	return nil, false
+}
+
+// jsonProblem describes an error decoding the named field, or the whole body if
+// field is empty, without exposing Go type names. Errors other than type
+// mismatches are described by fallback.
+func jsonProblem(err error, field string, fallback string) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return strings.TrimSpace(field + " must be " + describeKind(typeErr.Type))
+	}
+
+	return fallback
+}
+
+func bindStruct(r *http.Request, v reflect.Value, bindErr *BindError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			bindStruct(r, fv, bindErr)
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		for _, source := range bindSources {
+			name, ok := field.Tag.Lookup(source)
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+
+			values := sourceValues(r, source, name)
+			if len(values) == 0 {
+				continue
+			}
+
+			if err := setField(fv, values); err != nil {
+				bindErr.add(name, err.Error())
+			}
+		}
+	}
+}
+
+// sourceValues returns the request's values for `name` from the given source.
+func sourceValues(r *http.Request, source string, name string) []string {
+	switch source {
+	case "path":
+		if v, ok := Params(r)[name]; ok {
+			return []string{v}
+		}
+	case "query":
+		return r.URL.Query()[name]
+	case "form":
+		return r.PostForm[name]
+	case "header":
+		return r.Header.Values(name)
+	}
+
+	return nil
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setField converts the values to the type of the field and sets it. Fields that
+// aren't slices are set from the first value.
+func setField(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textUnmarshaler) &&
+		v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	return setValue(v, values[0])
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshaler) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("is not valid: %s", err)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("cannot be bound to a %s", v.Type())
+	}
+
+	return nil
+}
+
+// describeKind returns a client-friendly description of the kind of a type.
+func describeKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+
+	return "a string"
+}
+
+var (
+	requestType   = reflect.TypeOf((*http.Request)(nil))
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// bindingHandler creates an http.Handler for handlers that accept a struct,
+// such as:
+//
+//	func(input CreatePost) (interface{}, error)
+//	func(r *http.Request, input *CreatePost) (interface{}, error)
+//
+// The struct is filled with Bind and checked with ValidateStruct before the
+// handler is called, and a 422 response is written if either fails. ok is
+// false if fn doesn't have one of these shapes.
+func bindingHandler(fn interface{}) (http.Handler, bool) {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() < 1 || t.NumIn() > 2 || t.NumOut() != 2 {
+		return nil, false
+	}
+
+	if t.Out(0) != interfaceType || t.Out(1) != errorType {
+		return nil, false
+	}
+
+	if t.NumIn() == 2 && t.In(0) != requestType {
+		return nil, false
+	}
+
+	input := t.In(t.NumIn() - 1)
+	isPtr := input.Kind() == reflect.Ptr
+	if isPtr {
+		input = input.Elem()
+	}
+	if input.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dst := reflect.New(input)
+		if err := Bind(r, dst.Interface()); err != nil {
+			writeValue(w, r, nil, err)
+			return
+		}
+
+		if err := ValidateStruct(dst.Interface()); err != nil {
+			writeValue(w, r, nil, err)
+			return
+		}
+
+		arg := dst
+		if !isPtr {
+			arg = dst.Elem()
+		}
+
+		in := []reflect.Value{arg}
+		if t.NumIn() == 2 {
+			in = []reflect.Value{reflect.ValueOf(r), arg}
+		}
+
+		out := v.Call(in)
+		err, _ := out[1].Interface().(error)
+		writeValue(w, r, out[0].Interface(), err)
+	}), true
+}
@@ -0,0 +1,137 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+type pagination struct {
+	Page    int  `query:"page"`
+	PerPage *int `query:"per_page"`
+}
+
+type updatePost struct {
+	pagination
+	ID      uint64    `path:"id"`
+	Tenant  string    `header:"X-Tenant"`
+	Tags    []string  `query:"tag"`
+	Publish bool      `form:"publish"`
+	At      time.Time `query:"at"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+}
+
+func TestBind(t *testing.T) {
+	var bound updatePost
+	var bindErr error
+
+	r := router.New()
+	r.Put("posts/{id}", func(w http.ResponseWriter, req *http.Request) {
+		bindErr = router.Bind(req, &bound)
+	})
+
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/posts/42?page=2&per_page=10&tag=go&tag=http&at=2024-01-02T03:04:05Z",
+		strings.NewReader(`{"title":"Hello","body":"World"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NoError(t, bindErr)
+	assert.Equal(t, uint64(42), bound.ID)
+	assert.Equal(t, 2, bound.Page)
+	if assert.NotNil(t, bound.PerPage) {
+		assert.Equal(t, 10, *bound.PerPage)
+	}
+	assert.Equal(t, "acme", bound.Tenant)
+	assert.Equal(t, []string{"go", "http"}, bound.Tags)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), bound.At)
+	assert.Equal(t, "Hello", bound.Title)
+	assert.Equal(t, "World", bound.Body)
+}
+
+func TestBindForm(t *testing.T) {
+	var bound updatePost
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("publish=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	assert.NoError(t, router.Bind(req, &bound))
+	assert.True(t, bound.Publish)
+}
+
+func TestBindCollectsFieldErrors(t *testing.T) {
+	r := router.New()
+	r.Put("posts/{id}", func(req *http.Request) (interface{}, error) {
+		var bound updatePost
+		if err := router.Bind(req, &bound); err != nil {
+			return nil, err
+		}
+		return bound, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/posts/abc?page=two", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid request: id: must be a positive integer; page: must be an integer",
+		"fields": {
+			"id": ["must be a positive integer"],
+			"page": ["must be an integer"]
+		}
+	}`, rec.Body.String())
+}
+
+func TestBindOnlySetsJSONTaggedFieldsFromBody(t *testing.T) {
+	var bound struct {
+		updatePost
+		Role    string
+		Ignored string `json:"-"`
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(
+		`{"title":"Hello","tenant":"other","publish":true,"role":"admin","Ignored":"x"}`,
+	))
+	req.Header.Set("Content-Type", "application/json")
+
+	assert.NoError(t, router.Bind(req, &bound))
+	assert.Equal(t, "Hello", bound.Title)
+	assert.Empty(t, bound.Tenant)
+	assert.False(t, bound.Publish)
+	assert.Empty(t, bound.Role)
+	assert.Empty(t, bound.Ignored)
+}
+
+func TestBindJSONFieldErrors(t *testing.T) {
+	var bound updatePost
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"title":5}`))
+	req.Header.Set("Content-Type", "application/json")
+	err := router.Bind(req, &bound)
+	assert.EqualError(t, err, "invalid request: body: title must be a string")
+
+	req = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"title":`))
+	req.Header.Set("Content-Type", "application/json")
+	err = router.Bind(req, &bound)
+	assert.EqualError(t, err, "invalid request: body: must be valid JSON")
+}
+
+func TestBindRequiresStructPointer(t *testing.T) {
+	var s string
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	err := router.Bind(req, &s)
+	assert.Error(t, err)
+	_, isBindErr := err.(router.BindError)
+	assert.False(t, isBindErr)
+}
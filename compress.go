@@ -0,0 +1,313 @@
+package router
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// minCompressSize is the smallest response body that Compress compresses.
+// Smaller bodies are sent as they are, since compressing them saves little.
+const minCompressSize = 1024
+
+// incompressible lists content types that are already compressed. Types ending
+// in `/` match any subtype.
+var incompressible = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"font/woff2",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+}
+
+// compression is the state shared between Compress and NoCompress for a single
+// request.
+type compression struct {
+	disabled bool
+}
+
+// Compress returns middleware that compresses responses with gzip or deflate,
+// depending on the request's Accept-Encoding header. Bodies smaller than 1KB,
+// responses that already have a Content-Encoding and content types that are
+// already compressed, such as images, are sent uncompressed. A level of 0 uses
+// the default compression level; otherwise it must be a valid gzip level.
+//
+// Flushing the response, for example from a streaming handler, flushes any data
+// waiting to be compressed. To stop a single route's responses from being
+// compressed, add the NoCompress middleware to it.
+func Compress(level int) Middleware {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic("router: " + err.Error())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				w.Header().Add("Vary", "Accept-Encoding")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			state := &compression{}
+			r = r.WithContext(context.WithValue(r.Context(), compressKey, state))
+			cw := &compressWriter{
+				ResponseWriter: w,
+				r:              r,
+				encoding:       encoding,
+				level:          level,
+				state:          state,
+			}
+
+			next.ServeHTTP(cw, r)
+			if err := cw.Close(); err != nil {
+				ReportError(r, err)
+			}
+		})
+	}
+}
+
+// NoCompress is middleware that stops Compress from compressing responses. Add it
+// to routes whose responses shouldn't be compressed, such as event streams.
+func NoCompress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if state, ok := r.Context().Value(compressKey).(*compression); ok {
+			state.disabled = true
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// negotiateEncoding chooses the encoding for a response from the request's
+// Accept-Encoding header. gzip is preferred over deflate when both are equally
+// acceptable. An empty string is returned if neither is acceptable.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		coding, q := parseCoding(part)
+		switch coding {
+		case "gzip", "deflate":
+		case "*":
+			coding = "gzip"
+		default:
+			continue
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && coding == "gzip") {
+			best, bestQ = coding, q
+		}
+	}
+
+	return best
+}
+
+// parseCoding parses a single element of an Accept-Encoding header into the
+// content coding and its quality value.
+func parseCoding(part string) (string, float64) {
+	coding, params, _ := strings.Cut(part, ";")
+	coding = strings.ToLower(strings.TrimSpace(coding))
+
+	q := 1.0
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if strings.TrimSpace(strings.ToLower(name)) != "q" {
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			q = v
+		}
+	}
+
+	return coding, q
+}
+
+// compressible determines whether responses of the given content type are worth
+// compressing.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range incompressible {
+		family := strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)
+		if mediaType == t || (family && mediaType != "image/svg+xml") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compressWriter compresses the response written through it. The start of the
+// body is buffered until there is enough of it to decide whether to compress.
+type compressWriter struct {
+	http.ResponseWriter
+	// r is the request being responded to, which errors that can't be
+	// returned to the handler are reported against.
+	r        *http.Request
+	encoding string
+	level    int
+	state    *compression
+
+	status  int
+	buf     []byte
+	decided bool
+	w       io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.status != 0 {
+		return
+	}
+
+	// Informational responses are sent straight away, and don't start the
+	// response proper.
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.status = code
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		if cw.w != nil {
+			return cw.w.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= minCompressSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush sends any buffered data to the client. If the response hasn't been
+// started, it is compressed if it otherwise would be, regardless of its size.
+// Flush can't return errors, so they are reported with ReportError.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			ReportError(cw.r, err)
+			return
+		}
+	}
+
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			ReportError(cw.r, err)
+			return
+		}
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for use by
+// http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close finishes the response, writing any buffered data.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			cw.Header().Add("Vary", "Accept-Encoding")
+			return nil
+		}
+
+		if err := cw.decide(len(cw.buf) >= minCompressSize); err != nil {
+			return err
+		}
+	}
+
+	if cw.w != nil {
+		return cw.w.Close()
+	}
+
+	return nil
+}
+
+// decide starts the response, compressing it if it is large enough, of a
+// compressible type and hasn't been disabled with NoCompress. Buffered data is
+// then written.
+func (cw *compressWriter) decide(largeEnough bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if !cw.state.disabled {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if !cw.state.disabled && largeEnough && bodyAllowed(status) &&
+		h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+
+		if cw.encoding == "gzip" {
+			cw.w, _ = gzip.NewWriterLevel(cw.ResponseWriter, cw.level)
+		} else {
+			cw.w, _ = zlib.NewWriterLevel(cw.ResponseWriter, cw.level)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.w != nil {
+		_, err = cw.w.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// bodyAllowed determines whether a response with the given status has a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,150 @@
+package router_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+var longText = strings.Repeat("hello, world. ", 200)
+
+func TestCompressNegotiatesEncoding(t *testing.T) {
+	r := router.New().Middleware(router.Compress(0))
+	r.Get("text", func() string {
+		return longText
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/text", nil)
+	rec := record(r, req, "Accept-Encoding", "deflate, gzip")
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	gz, err := gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(gz)
+		assert.Equal(t, longText, string(body))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/text", nil)
+	rec = record(r, req, "Accept-Encoding", "gzip;q=0.5, deflate")
+	assert.Equal(t, "deflate", rec.Header().Get("Content-Encoding"))
+	zr, err := zlib.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(zr)
+		assert.Equal(t, longText, string(body))
+	}
+
+	for _, header := range []string{"", "br", "gzip;q=0, identity"} {
+		rec = record(r, httptest.NewRequest(http.MethodGet, "/text", nil), "Accept-Encoding", header)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"), header)
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"), header)
+		assert.Equal(t, longText, rec.Body.String(), header)
+	}
+}
+
+func TestCompressSkipsSmallAndCompressedBodies(t *testing.T) {
+	r := router.New().Middleware(router.Compress(0))
+	r.Get("small", func() string {
+		return "hello"
+	})
+	r.Get("image", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(longText))
+	})
+	r.Get("svg", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(longText))
+	})
+	r.Get("empty", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/small", nil), "Accept-Encoding", "gzip")
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "hello", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/image", nil), "Accept-Encoding", "gzip")
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, longText, rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/svg", nil), "Accept-Encoding", "gzip")
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/empty", nil), "Accept-Encoding", "gzip")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+}
+
+func TestNoCompress(t *testing.T) {
+	r := router.New().Middleware(router.Compress(gzip.BestSpeed))
+	r.Get("events", func() string {
+		return longText
+	}).Middleware(router.NoCompress)
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/events", nil), "Accept-Encoding", "gzip")
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, longText, rec.Body.String())
+}
+
+func TestCompressFlushesStreamingResponses(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var flushed int
+
+	r := router.New()
+	r.Group(
+		router.Get("stream", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("first"))
+			w.(http.Flusher).Flush()
+			flushed = rec.Body.Len()
+			w.Write([]byte(" second"))
+		}),
+	).Middleware(router.Compress(0))
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.True(t, rec.Flushed)
+	assert.NotZero(t, flushed)
+
+	gz, err := gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(gz)
+		assert.Equal(t, "first second", string(body))
+	}
+}
+
+func TestCompressReportsWriteErrors(t *testing.T) {
+	var reported []error
+	r := router.New().OnError(func(_ *http.Request, err error) {
+		reported = append(reported, err)
+	})
+	r.Group(
+		router.Get("stream", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("first"))
+			w.(http.Flusher).Flush()
+		}),
+		router.Get("small", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("small"))
+		}),
+	).Middleware(router.Compress(0))
+
+	for _, path := range []string{"/stream", "/small"} {
+		reported = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		r.ServeHTTP(failingWriter{httptest.NewRecorder()}, req)
+		if assert.NotEmpty(t, reported, path) {
+			assert.EqualError(t, reported[0], "connection reset", path)
+		}
+	}
+}
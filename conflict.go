@@ -0,0 +1,176 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// ConflictKind describes how two route definitions interfere with each other.
+type ConflictKind int
+
+const (
+	// Duplicate routes share a method and an identical pattern, so only one of
+	// them is ever served.
+	Duplicate ConflictKind = iota
+	// Ambiguous routes are equally specific and can both match the same
+	// request, so which one is served depends on the order they were
+	// registered in.
+	Ambiguous
+	// Unreachable routes match only requests that a route with a higher
+	// precedence already matches, so they are never served.
+	Unreachable
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case Duplicate:
+		return "duplicate"
+	case Ambiguous:
+		return "ambiguous"
+	case Unreachable:
+		return "unreachable"
+	}
+
+	return fmt.Sprintf("ConflictKind(%d)", int(k))
+}
+
+// Conflict is a single problem found in a router's route table. Route is the
+// definition that loses out to Other for the given Methods.
+type Conflict struct {
+	Kind    ConflictKind
+	Route   *Route
+	Other   *Route
+	Methods []string
+}
+
+func (c Conflict) String() string {
+	methods := strings.Join(c.Methods, ",")
+
+	var relation string
+	switch c.Kind {
+	case Duplicate:
+		relation = "duplicates"
+	case Ambiguous:
+		relation = "overlaps"
+	default:
+		relation = "is shadowed by"
+	}
+
+	return fmt.Sprintf(
+		"%s: %s %s (%s) %s %s %s (%s)",
+		c.Kind,
+		methods, c.Route.Pattern(), c.Route.Site(),
+		relation,
+		methods, c.Other.Pattern(), c.Other.Site(),
+	)
+}
+
+// ConflictError is returned by Validate when the route table contains conflicts.
+type ConflictError []Conflict
+
+func (e ConflictError) Error() string {
+	lines := []string{fmt.Sprintf("router: %d route conflict(s):", len(e))}
+	for _, c := range e {
+		lines = append(lines, "\t"+c.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Validate inspects the routes registered on the router and reports any that
+// are duplicated, ambiguous or unreachable as a ConflictError. Constrained
+// parameters are compared by their pattern only, so two different constraints
+// in the same position are conservatively reported as ambiguous.
+func (router *Router) Validate() error {
+	return router.routes().validate()
+}
+
+// MustValidate is like Validate, but panics if the routes conflict. Call it once
+// the routes are registered, before the router starts serving requests.
+func (router *Router) MustValidate() *Router {
+	if err := router.Validate(); err != nil {
+		panic(err)
+	}
+	return router
+}
+
+// validate reports the conflicts in the table. The result is computed once per
+// table, as tables are never modified after they are published.
+func (t *table) validate() error {
+	t.validated.Do(func() {
+		var conflicts ConflictError
+		for i, earlier := range t.routes {
+			for _, later := range t.routes[i+1:] {
+				if c, ok := conflictBetween(earlier, later); ok {
+					conflicts = append(conflicts, c)
+				}
+			}
+		}
+
+		if len(conflicts) > 0 {
+			t.err = conflicts
+		}
+	})
+
+	return t.err
+}
+
+// conflictBetween compares two routes, where `earlier` takes precedence over
+// `later` when both match a request.
+func conflictBetween(earlier *Route, later *Route) (Conflict, bool) {
+	methods := sharedMethods(earlier, later)
+	if len(methods) == 0 || earlier.version != later.version {
+		return Conflict{}, false
+	}
+
+	c := Conflict{Route: later, Other: earlier, Methods: methods}
+
+	a, b := earlier.segments, later.segments
+	switch {
+	case earlier.Pattern() == later.Pattern():
+		c.Kind = Duplicate
+	case covers(a, b):
+		c.Kind = Unreachable
+	case compare(earlier, later) == 0 && overlaps(a, b):
+		c.Kind = Ambiguous
+	default:
+		return Conflict{}, false
+	}
+
+	return c, true
+}
+
+func sharedMethods(a *Route, b *Route) []string {
+	var shared []string
+	for _, m := range a.methods {
+		for _, n := range b.methods {
+			if m == n {
+				shared = append(shared, m)
+			}
+		}
+	}
+
+	return shared
+}
+
+// packagePath is the import path of this package, used to skip its own frames
+// when looking for the site a route was defined at.
+var packagePath = reflect.TypeOf((*Router)(nil)).Elem().PkgPath()
+
+// callerSite returns the file:line of the first caller outside this package.
+func callerSite() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePath+".") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReportsConflicts(t *testing.T) {
+	type testcase struct {
+		setup    func(r *router.Router)
+		expected []router.ConflictKind
+	}
+
+	cases := map[string]testcase{
+		"no conflicts": {
+			setup: func(r *router.Router) {
+				r.Get("/users/me", helloHandler)
+				r.Get("/users/{id}", helloHandler)
+				r.Post("/users/{id}", helloHandler)
+			},
+		},
+		"duplicate across groups": {
+			setup: func(r *router.Router) {
+				r.Get("/users", helloHandler)
+				r.Group(router.Get("/users", helloHandler))
+			},
+			expected: []router.ConflictKind{router.Duplicate},
+		},
+		"prioritised parameter shadows static segment": {
+			setup: func(r *router.Router) {
+				r.Get("/users/{id}", helloHandler).Priority(1)
+				r.Get("/users/me", helloHandler)
+			},
+			expected: []router.ConflictKind{router.Unreachable},
+		},
+		"prioritised constraint shadows matching static segment": {
+			setup: func(r *router.Router) {
+				r.Get("/posts/{id:[0-9]+}", helloHandler).Priority(1)
+				r.Get("/posts/42", helloHandler)
+				r.Get("/posts/latest", helloHandler)
+			},
+			expected: []router.ConflictKind{router.Unreachable},
+		},
+		"equally specific overlapping constraints": {
+			setup: func(r *router.Router) {
+				r.Get("/files/{id:[0-9]+}", helloHandler)
+				r.Group(router.Get("/{hash:[a-f0-9]+}", helloHandler)).Prefix("files")
+			},
+			expected: []router.ConflictKind{router.Ambiguous},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := router.New()
+			tc.setup(r)
+
+			err := r.Validate()
+			if tc.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			conflicts, ok := err.(router.ConflictError)
+			if !assert.True(t, ok) {
+				return
+			}
+
+			var kinds []router.ConflictKind
+			for _, c := range conflicts {
+				kinds = append(kinds, c.Kind)
+			}
+			assert.Equal(t, tc.expected, kinds)
+		})
+	}
+}
+
+func TestConflictsReportRegistrationSites(t *testing.T) {
+	r := router.New()
+	shadowing := r.Get("/users/{id}", helloHandler).Priority(1)
+	shadowed := r.Get("/users/me", helloHandler)
+
+	assert.Regexp(t, `conflict_test\.go:\d+$`, shadowing.Site())
+	assert.NotEqual(t, shadowing.Site(), shadowed.Site())
+
+	err := r.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), shadowing.Site())
+		assert.Contains(t, err.Error(), shadowed.Site())
+	}
+}
+
+func TestMustValidatePanicsOnConflicts(t *testing.T) {
+	r := router.New()
+	r.Get("/users", helloHandler)
+
+	assert.NotPanics(t, func() { r.MustValidate() })
+
+	r.Group(router.Get("/users", helloHandler))
+	assert.Panics(t, func() { r.MustValidate() })
+
+	// Conflicts never cause requests to fail.
+	r.Get("/ok", helloHandler)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
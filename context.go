@@ -0,0 +1,145 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+type contextKey int
+
+const (
+	paramsKey contextKey = iota
+	mountPathKey
+	mountKey
+	versionKey
+	routeKey
+	requestIDKey
+	compressKey
+	csrfKey
+	modelsKey
+	nonceKey
+	servedKey
+	servedHooksKey
+)
+
+// Param returns the value of the named route parameter for the request. If an
+// optional parameter was omitted from the path, its default value is returned.
+func Param(r *http.Request, name string) string {
+	return Params(r)[name]
+}
+
+// Params returns all of the route parameters for the request, keyed by name.
+// Unlike the request's Form, the values can't be overridden by the query
+// string.
+func Params(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(paramsKey).(map[string]string)
+	return params
+}
+
+// withParams returns a shallow copy of the request with the route parameters
+// attached to its context.
+func withParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), paramsKey, params))
+}
+
+// CurrentRoute returns the route that matched the request, or nil if the request
+// hasn't been matched to a route.
+func CurrentRoute(r *http.Request) *Route {
+	route, _ := r.Context().Value(routeKey).(*Route)
+	return route
+}
+
+// withRoute returns a shallow copy of the request with the matched route
+// attached to its context.
+func withRoute(r *http.Request, route *Route) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeKey, route))
+}
+
+// servedRoute is the route serving a request, along with its full pattern.
+type servedRoute struct {
+	route   *Route
+	pattern string
+}
+
+// ServedRoute returns the route serving the request and its full pattern, or nil
+// and an empty string if the request hasn't been matched to a route. Unlike
+// CurrentRoute, it sees through Mount. Once a mounted router has matched the
+// request, the route it matched is returned, with the mount prefix added to its
+// pattern. Until then, or if the mounted handler isn't a *Router, the mount
+// point is returned, with the prefix as its pattern.
+//
+// Middleware must call ServedRoute after calling the next handler to see the
+// routes matched by mounted routers.
+func ServedRoute(r *http.Request) (*Route, string) {
+	p, _ := r.Context().Value(servedKey).(*atomic.Pointer[servedRoute])
+	if p == nil {
+		return nil, ""
+	}
+
+	s := p.Load()
+	if s == nil {
+		return nil, ""
+	}
+
+	return s.route, s.pattern
+}
+
+// withServed records the route as the one serving the request, for ServedRoute.
+// Requests passed to a mounted router keep the record of the outermost router,
+// so that it sees the route the mounted router matched.
+func withServed(r *http.Request, route *Route) *http.Request {
+	pattern := route.pattern
+	if route.mount != nil {
+		pattern = route.mountPattern()
+	}
+
+	p, _ := r.Context().Value(servedKey).(*atomic.Pointer[servedRoute])
+	if p == nil || mountOf(r) == nil {
+		p = new(atomic.Pointer[servedRoute])
+		r = r.WithContext(context.WithValue(r.Context(), servedKey, p))
+	}
+	p.Store(&servedRoute{route: route, pattern: mountPattern(r) + pattern})
+
+	return r
+}
+
+// servedHook is called with the route serving a request. It returns the request
+// to serve the route with, or nil if it has responded to the request itself.
+type servedHook func(w http.ResponseWriter, r *http.Request, route *Route) *http.Request
+
+// whenServed calls fn with the route serving the request, then serves the
+// request fn returns with next. If the request was matched to the mount point of
+// a *Router, the route serving it isn't known until that router has matched it,
+// so fn is called by that router instead, before its own middleware runs.
+func whenServed(w http.ResponseWriter, r *http.Request, next http.Handler, fn servedHook) {
+	if route := CurrentRoute(r); route != nil && route.mountsRouter() {
+		hooks, _ := r.Context().Value(servedHooksKey).([]servedHook)
+		hooks = append(hooks[:len(hooks):len(hooks)], fn)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), servedHooksKey, hooks)))
+		return
+	}
+
+	if r = fn(w, r, CurrentRoute(r)); r != nil {
+		next.ServeHTTP(w, r)
+	}
+}
+
+// runServedHooks calls the hooks that middleware on the routers the request was
+// mounted beneath left for the route serving it. It returns nil if a hook has
+// responded to the request.
+func runServedHooks(w http.ResponseWriter, r *http.Request, route *Route) *http.Request {
+	hooks, _ := r.Context().Value(servedHooksKey).([]servedHook)
+	if len(hooks) == 0 || route.mountsRouter() {
+		return r
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), servedHooksKey, []servedHook(nil)))
+	for _, fn := range hooks {
+		if r = fn(w, r, route); r == nil {
+			return nil
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,207 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+)
+
+// csrfSecretLength is the length in bytes of the secret stored in the CSRF
+// cookie.
+const csrfSecretLength = 32
+
+// CSRFConfig configures the CSRF middleware. The zero value is ready to use.
+type CSRFConfig struct {
+	// Cookie is the name of the cookie holding the CSRF secret. It defaults to
+	// "csrf_token".
+	Cookie string
+	// Header is the request header that AJAX requests send the token in. It
+	// defaults to "X-CSRF-Token".
+	Header string
+	// Field is the form field that forms send the token in. It defaults to
+	// "_token".
+	Field string
+
+	// Path, Domain, Secure and SameSite are set on the cookie. Path defaults
+	// to "/", and SameSite to http.SameSiteLaxMode.
+	Path     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+
+	// Failed writes the response to requests without a valid token. By
+	// default, a 403 response is sent.
+	Failed http.Handler
+}
+
+// csrfState is the CSRF secret and form field for a request, used by CSRFToken
+// and CSRFField.
+type csrfState struct {
+	secret []byte
+	field  string
+}
+
+// CSRF returns middleware that protects routes from cross-site request forgery
+// using double-submit cookies. A random secret is stored in a cookie, and
+// requests that can change state must send a token derived from it, either in
+// a form field or a request header. Other sites can't read the cookie, so can't
+// send the token.
+//
+// GET, HEAD, OPTIONS and TRACE requests aren't checked, nor are requests to
+// routes marked with Route.CSRFExempt. Templates can include the token in forms
+// with CSRFField, and scripts can read it from a page that includes CSRFToken.
+func CSRF(c CSRFConfig) Middleware {
+	if c.Cookie == "" {
+		c.Cookie = "csrf_token"
+	}
+	if c.Header == "" {
+		c.Header = "X-CSRF-Token"
+	}
+	if c.Field == "" {
+		c.Field = "_token"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.Failed == nil {
+		c.Failed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("403 forbidden: invalid CSRF token"))
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret, existing := csrfSecret(r, c.Cookie)
+			if !existing {
+				http.SetCookie(w, &http.Cookie{
+					Name:     c.Cookie,
+					Value:    base64.RawURLEncoding.EncodeToString(secret),
+					Path:     c.Path,
+					Domain:   c.Domain,
+					Secure:   c.Secure,
+					HttpOnly: true,
+					SameSite: c.SameSite,
+				})
+			}
+			w.Header().Add("Vary", "Cookie")
+
+			state := &csrfState{secret: secret, field: c.Field}
+			r = r.WithContext(context.WithValue(r.Context(), csrfKey, state))
+
+			// Routes on mounted routers can be exempt, so the token is checked
+			// once the route serving the request is known.
+			whenServed(w, r, next, func(w http.ResponseWriter, r *http.Request, route *Route) *http.Request {
+				if !csrfChecked(r, route) {
+					return r
+				}
+
+				token := r.Header.Get(c.Header)
+				if token == "" {
+					token = r.PostFormValue(c.Field)
+				}
+
+				if !existing || !validCSRFToken(token, secret) {
+					c.Failed.ServeHTTP(w, r)
+					return nil
+				}
+
+				return r
+			})
+		})
+	}
+}
+
+// csrfChecked determines whether a request to the route must have a valid CSRF
+// token. Methods that don't change state aren't checked, nor are exempt routes.
+func csrfChecked(r *http.Request, route *Route) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	return route == nil || !route.csrfExempt
+}
+
+// CSRFToken returns the CSRF token to send with requests, for use in templates
+// and scripts. A different token is returned each time, so that the secret can't
+// be recovered from compressed responses. It returns an empty string if the
+// request wasn't served by the CSRF middleware.
+func CSRFToken(r *http.Request) string {
+	state, ok := r.Context().Value(csrfKey).(*csrfState)
+	if !ok {
+		return ""
+	}
+
+	return maskCSRFSecret(state.secret)
+}
+
+// CSRFField returns a hidden form input containing the CSRF token, for use in
+// templates:
+//
+//	<form method="post">{{ .CSRFField }} ... </form>
+func CSRFField(r *http.Request) template.HTML {
+	state, ok := r.Context().Value(csrfKey).(*csrfState)
+	if !ok {
+		return ""
+	}
+
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(state.field) +
+		`" value="` + maskCSRFSecret(state.secret) + `">`)
+}
+
+// csrfSecret returns the secret from the request's CSRF cookie. If the request
+// doesn't have a valid cookie, a new secret is generated and existing is false.
+func csrfSecret(r *http.Request, name string) (secret []byte, existing bool) {
+	if cookie, err := r.Cookie(name); err == nil {
+		secret, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+		if err == nil && len(secret) == csrfSecretLength {
+			return secret, true
+		}
+	}
+
+	secret = make([]byte, csrfSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+
+	return secret, false
+}
+
+// maskCSRFSecret returns a token for the secret, made of a random mask followed
+// by the secret XORed with the mask.
+func maskCSRFSecret(secret []byte) string {
+	token := make([]byte, 2*len(secret))
+	mask := token[:len(secret)]
+	if _, err := rand.Read(mask); err != nil {
+		panic(err)
+	}
+
+	for i := range secret {
+		token[len(secret)+i] = secret[i] ^ mask[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// validCSRFToken determines whether token was made from secret by
+// maskCSRFSecret.
+func validCSRFToken(token string, secret []byte) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != 2*len(secret) {
+		return false
+	}
+
+	unmasked := make([]byte, len(secret))
+	for i := range secret {
+		unmasked[i] = b[i] ^ b[len(secret)+i]
+	}
+
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
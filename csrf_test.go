@@ -0,0 +1,143 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+var csrfField = regexp.MustCompile(`^<input type="hidden" name="_token" value="([^"]+)">$`)
+
+// csrfSession fetches the page at /form from the router, returning the CSRF
+// cookie and the token from the page.
+func csrfSession(t *testing.T, r *router.Router) (*http.Cookie, string) {
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/form", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "csrf_token", cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	match := csrfField.FindStringSubmatch(rec.Body.String())
+	if !assert.NotNil(t, match) {
+		t.FailNow()
+	}
+
+	return cookies[0], match[1]
+}
+
+func TestCSRFFormToken(t *testing.T) {
+	r := router.New().Middleware(router.CSRF(router.CSRFConfig{}))
+	r.Get("form", func(req *http.Request) string {
+		return string(router.CSRFField(req))
+	})
+	r.Post("submit", func() string {
+		return "ok"
+	})
+
+	submit := func(cookie *http.Cookie, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(form.Encode()))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		return record(r, req, "Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	cookie, token := csrfSession(t, r)
+
+	rec := submit(cookie, url.Values{"_token": {token}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+
+	rec = submit(cookie, url.Values{})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = submit(cookie, url.Values{"_token": {"forged"}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = submit(nil, url.Values{"_token": {token}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	_, otherToken := csrfSession(t, r)
+	rec = submit(cookie, url.Values{"_token": {otherToken}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFHeaderToken(t *testing.T) {
+	r := router.New().Middleware(router.CSRF(router.CSRFConfig{}))
+	r.Get("form", func(req *http.Request) string {
+		return string(router.CSRFField(req))
+	})
+	r.Get("token", func(req *http.Request) string {
+		return router.CSRFToken(req)
+	})
+	r.Post("submit", func() string {
+		return "ok"
+	})
+
+	cookie, _ := csrfSession(t, r)
+
+	req := httptest.NewRequest(http.MethodGet, "/token", nil)
+	req.AddCookie(cookie)
+	token := record(r, req).Body.String()
+	assert.NotEmpty(t, token)
+
+	req = httptest.NewRequest(http.MethodPost, "/submit", nil)
+	req.AddCookie(cookie)
+	rec := record(r, req, "X-CSRF-Token", token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCSRFExemptRoutes(t *testing.T) {
+	r := router.New().Middleware(router.CSRF(router.CSRFConfig{}))
+	r.Post("webhook", func() string {
+		return "ok"
+	}).CSRFExempt()
+
+	rec := record(r, httptest.NewRequest(http.MethodPost, "/webhook", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCSRFExemptMountedRoutes(t *testing.T) {
+	billing := router.New()
+	billing.Post("webhook", func() string {
+		return "ok"
+	}).CSRFExempt()
+	billing.Post("charge", func() string {
+		return "charged"
+	})
+
+	r := router.New().Middleware(router.CSRF(router.CSRFConfig{}))
+	r.Mount("/billing", billing)
+
+	rec := record(r, httptest.NewRequest(http.MethodPost, "/billing/webhook", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodPost, "/billing/charge", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCSRFFailureHandler(t *testing.T) {
+	r := router.New().Middleware(router.CSRF(router.CSRFConfig{
+		Failed: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(419)
+			w.Write([]byte("page expired"))
+		}),
+	}))
+	r.Post("submit", func() string {
+		return "ok"
+	})
+
+	rec := record(r, httptest.NewRequest(http.MethodPost, "/submit", nil))
+	assert.Equal(t, 419, rec.Code)
+	assert.Equal(t, "page expired", rec.Body.String())
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Encoder writes the values returned by value-returning handlers, such as
+// func() (interface{}, error), to the response body.
+type Encoder interface {
+	// ContentType returns the media type of the encoded values.
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+}
+
+// JSON encodes values with encoding/json. It is the router's default Encoder.
+var JSON Encoder = jsonEncoder{}
+
+// XML encodes values with encoding/xml.
+var XML Encoder = xmlEncoder{}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (xmlEncoder) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// Encoder sets the Encoder used to write the values returned by handlers.
+func (router *Router) Encoder(enc Encoder) *Router {
+	router.update(func() {
+		router.encoder = enc
+	})
+	return router
+}
+
+// HTTPError is an error that determines the status code of the response when it
+// is returned by a handler. Other errors result in a 500 response.
+type HTTPError interface {
+	error
+	StatusCode() int
+}
+
+// StatusError is an HTTPError that wraps an error with a status code.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e StatusError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e StatusError) StatusCode() int {
+	return e.Code
+}
+
+func (e StatusError) Unwrap() error {
+	return e.Err
+}
+
+// fieldErrors is implemented by errors that describe problems with individual
+// fields of a request, such as BindError.
+type fieldErrors interface {
+	FieldErrors() map[string][]string
+}
+
+// errorBody is the response body written when a handler returns an error.
+type errorBody struct {
+	XMLName xml.Name            `json:"-" xml:"error"`
+	Message string              `json:"error" xml:"message"`
+	Fields  map[string][]string `json:"fields,omitempty" xml:"-"`
+}
+
+// writeValue encodes v as the response body with the matched route's Encoder.
+// If err is not nil, an error response is written instead. A nil value with no
+// error results in a 204 response.
+func writeValue(w http.ResponseWriter, r *http.Request, v interface{}, err error) {
+	enc := JSON
+	if route := CurrentRoute(r); route != nil && route.encoder != nil {
+		enc = route.encoder
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		status, v = errorResponse(r, err)
+	}
+
+	if v == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Encode into a buffer first, so an encoding failure can still be sent to
+	// the client as a 500.
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, v); err != nil {
+		ReportError(r, err)
+		status = http.StatusInternalServerError
+		buf.Reset()
+		if err := enc.Encode(&buf, errorBody{Message: http.StatusText(status)}); err != nil {
+			ReportError(r, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(status)
+	if _, err := buf.WriteTo(w); err != nil {
+		ReportError(r, err)
+	}
+}
+
+// errorResponse returns the status code and body for an error returned by a
+// handler. The messages of errors that aren't an HTTPError are not sent to the
+// client, and are reported to the router's error hook instead.
+func errorResponse(r *http.Request, err error) (int, interface{}) {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		body := errorBody{Message: httpErr.Error()}
+
+		var fields fieldErrors
+		if errors.As(err, &fields) {
+			body.Fields = fields.FieldErrors()
+		}
+
+		return httpErr.StatusCode(), body
+	}
+
+	ReportError(r, err)
+
+	status := http.StatusInternalServerError
+	return status, errorBody{Message: http.StatusText(status)}
+}
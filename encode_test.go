@@ -0,0 +1,112 @@
+package router_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestValueHandlers(t *testing.T) {
+	type testcase struct {
+		handler     interface{}
+		status      int
+		contentType string
+		body        string
+	}
+
+	cases := map[string]testcase{
+		"value is encoded": {
+			handler: func() (interface{}, error) {
+				return user{ID: 1, Name: "Ada"}, nil
+			},
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id":1,"name":"Ada"}` + "\n",
+		},
+		"request is passed to handler": {
+			handler: func(r *http.Request) (interface{}, error) {
+				return map[string]string{"id": router.Param(r, "id")}, nil
+			},
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id":"7"}` + "\n",
+		},
+		"nil value has no content": {
+			handler: func() (interface{}, error) {
+				return nil, nil
+			},
+			status: http.StatusNoContent,
+		},
+		"http errors set the status": {
+			handler: func() (interface{}, error) {
+				return nil, fmt.Errorf("loading user: %w", router.StatusError{
+					Code: http.StatusNotFound,
+					Err:  errors.New("user not found"),
+				})
+			},
+			status:      http.StatusNotFound,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"error":"user not found"}` + "\n",
+		},
+		"other errors are hidden": {
+			handler: func() (interface{}, error) {
+				return nil, errors.New("database password is hunter2")
+			},
+			status:      http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"error":"Internal Server Error"}` + "\n",
+		},
+		"encoding errors are 500s": {
+			handler: func() (interface{}, error) {
+				return make(chan int), nil
+			},
+			status:      http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := router.New().OnError(func(*http.Request, error) {})
+			r.Get("users/{id}", tc.handler)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.contentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tc.body, rec.Body.String())
+		})
+	}
+}
+
+func TestEncoderCanBeSwapped(t *testing.T) {
+	r := router.New().Encoder(router.XML)
+	r.Get("/", func() (interface{}, error) {
+		return nil, router.StatusError{Code: http.StatusForbidden}
+	})
+	r.Get("/user", func() (interface{}, error) {
+		return user{ID: 1, Name: "Ada"}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/user", nil))
+	assert.Equal(t, "application/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "<user><id>1</id><name>Ada</name></user>", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "<error><message>Forbidden</message></error>", rec.Body.String())
+}
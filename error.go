@@ -0,0 +1,36 @@
+package router
+
+import (
+	"log"
+	"net/http"
+)
+
+// OnError sets the function called with errors that occur while serving a
+// request but can't be sent to the client, such as a failure to write the
+// response body. By default, these errors are written to the standard logger.
+func (router *Router) OnError(fn func(*http.Request, error)) *Router {
+	router.update(func() {
+		router.onError = fn
+	})
+	return router
+}
+
+// ReportError passes err to the error hook of the router serving the request,
+// or writes it to the standard logger if the router has no hook. Handlers and
+// middleware can use it to report errors that can't be sent to the client.
+func ReportError(r *http.Request, err error) {
+	if route := CurrentRoute(r); route != nil && route.onError != nil {
+		route.onError(r, err)
+		return
+	}
+
+	log.Printf("router: %s %s: %v", r.Method, r.URL.Path, err)
+}
+
+// ServerError reports err with ReportError and responds to the request with the
+// router's 500 response.
+func ServerError(w http.ResponseWriter, r *http.Request, err error) {
+	ReportError(r, err)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("500 internal server error"))
+}
@@ -0,0 +1,185 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagFunc returns the current ETag of the resource that a request refers to, or
+// an empty string if the resource doesn't exist.
+type ETagFunc func(*http.Request) (string, error)
+
+// conditional returns a handler that serves conditional requests for next.
+//
+// Responses to GET and HEAD requests are buffered so that an ETag can be set on
+// them. The ETag is the one set by the handler if it sets one, then the one
+// returned by current, and otherwise a weak ETag hashed from the body. Requests
+// whose If-None-Match or If-Modified-Since headers show the client has the
+// latest response receive a 304.
+//
+// For other methods, If-Match headers are compared with the ETag returned by
+// current, and requests that don't match are rejected with a 412 before next is
+// called. If current is nil, If-Match headers are ignored.
+func conditional(next http.Handler, current ETagFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			ifMatch := r.Header.Get("If-Match")
+			if ifMatch == "" || current == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tag, err := current(r)
+			if err != nil {
+				ServerError(w, r, err)
+				return
+			}
+
+			if !matchETag(ifMatch, tag, false) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				w.Write([]byte("412 precondition failed"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var tag string
+		if current != nil {
+			var err error
+			if tag, err = current(r); err != nil {
+				ReportError(r, err)
+				tag = ""
+			}
+
+			// The handler doesn't need to run if the client already has the
+			// current representation.
+			if tag != "" && matchETag(r.Header.Get("If-None-Match"), tag, true) {
+				notModified(w, tag)
+				return
+			}
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+		if bw.status != http.StatusOK {
+			w.WriteHeader(bw.status)
+			w.Write(bw.body.Bytes())
+			return
+		}
+
+		if w.Header().Get("ETag") == "" {
+			if tag == "" {
+				tag = weakETag(bw.body.Bytes())
+			}
+			w.Header().Set("ETag", tag)
+		}
+
+		if fresh(r, w.Header()) {
+			notModified(w, w.Header().Get("ETag"))
+			return
+		}
+
+		w.WriteHeader(bw.status)
+		w.Write(bw.body.Bytes())
+	})
+}
+
+// bufferedWriter holds the status and body of a response until they are
+// written by conditional. Headers are written to the wrapped ResponseWriter.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+// weakETag returns a weak ETag for a response body.
+func weakETag(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+
+	return fmt.Sprintf(`W/"%x-%x"`, len(body), h.Sum64())
+}
+
+// fresh determines whether the client making the request already has the
+// response with the given headers. If-Modified-Since is only considered when
+// the request has no If-None-Match header.
+func fresh(r *http.Request, header http.Header) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return matchETag(ifNoneMatch, header.Get("ETag"), true)
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// matchETag determines whether tag matches any of the ETags in a list from an
+// If-Match or If-None-Match header. Weak comparison ignores the W/ prefix, and
+// strong comparison never matches weak ETags.
+func matchETag(list string, tag string, weak bool) bool {
+	if tag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+				return true
+			}
+			continue
+		}
+
+		if candidate == tag && !strings.HasPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified writes a 304 response with the given ETag.
+func notModified(w http.ResponseWriter, tag string) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Set("ETag", tag)
+
+	w.WriteHeader(http.StatusNotModified)
+}
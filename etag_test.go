@@ -0,0 +1,144 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeakETags(t *testing.T) {
+	r := router.New()
+	r.Get("posts", func() string {
+		return "all the posts"
+	}).ETag(nil)
+	r.Get("plain", func() string {
+		return "no etag"
+	})
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	tag := rec.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Regexp(t, `^W/"[0-9a-f]+-[0-9a-f]+"$`, tag)
+	assert.Equal(t, "all the posts", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/posts", nil), "If-None-Match", tag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, tag, rec.Header().Get("ETag"))
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/posts", nil), "If-None-Match", `W/"stale"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
+
+func TestHandlerETagsAndLastModified(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r := router.New()
+	r.Group(
+		router.Get("posts/{id}", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			w.Write([]byte("post"))
+		}),
+		router.Get("missing", func(w http.ResponseWriter, req *http.Request) {
+			http.NotFound(w, req)
+		}),
+	).ETag(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	rec := record(r, req, "If-None-Match", `"v1", W/"v2"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, `"v2"`, rec.Header().Get("ETag"))
+
+	req = httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	rec = record(r, req, "If-Modified-Since", modified.Format(http.TimeFormat))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	rec = record(r, req, "If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "post", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/missing", nil), "If-None-Match", "*")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
+
+func TestCurrentETag(t *testing.T) {
+	calls := 0
+	current := func(req *http.Request) (string, error) {
+		return `"` + router.Param(req, "id") + `-v3"`, nil
+	}
+
+	r := router.New()
+	r.Get("posts/{id}", func() string {
+		calls++
+		return "post"
+	}).ETag(current)
+	r.Put("posts/{id}", func() string {
+		return "updated"
+	}).ETag(current)
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/posts/7", nil), "If-None-Match", `"7-v3"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, 0, calls)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/posts/7", nil))
+	assert.Equal(t, `"7-v3"`, rec.Header().Get("ETag"))
+	assert.Equal(t, 1, calls)
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/7", nil), "If-Match", `"7-v2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, "412 precondition failed", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/7", nil), "If-Match", `W/"7-v3"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/7", nil), "If-Match", `"7-v3"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "updated", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/7", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestETagRoutesAreAuthenticated(t *testing.T) {
+	calls := 0
+	current := func(*http.Request) (string, error) {
+		calls++
+		return `"v1"`, nil
+	}
+
+	r := router.New().Guard("api", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Get("posts/{id}", func() string { return "post" }).Auth("api").ETag(current)
+	r.Put("posts/{id}", func() string { return "updated" }).Auth("api").ETag(current)
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/posts/1", nil), "If-None-Match", "*")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/1", nil), "If-Match", `"v0"`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, 0, calls)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	rec = record(r, req, "If-None-Match", "*", "Authorization", "Bearer token")
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, `"v1"`, rec.Header().Get("ETag"))
+}
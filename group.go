@@ -1,5 +1,7 @@
 package router
 
+import "time"
+
 type Group struct {
 	prefix string
 	routes []*Route
@@ -7,6 +9,27 @@ type Group struct {
 	router *Router
 
 	middleware []Middleware
+
+	// version is the API version of the group's routes, if any.
+	version string
+
+	// timeout and maxBodySize apply to the group's routes, unless the routes
+	// set their own.
+	timeout     time.Duration
+	maxBodySize int64
+
+	// etag and currentETag enable conditional requests for the group's
+	// routes. See Route.ETag.
+	etag        bool
+	currentETag ETagFunc
+
+	// auth is the name of the guard that authenticates requests to the group's
+	// routes. See Route.Auth.
+	auth string
+
+	// abilities are required of requests to the group's routes. See
+	// Route.Can.
+	abilities []ability
 }
 
 func (g *Group) calculateRouteRegexs() {
@@ -15,11 +38,13 @@ func (g *Group) calculateRouteRegexs() {
 	}
 }
 
+// newGroup creates a group containing the given routes. Callers must hold
+// router.mu.
 func newGroup(router *Router, routes ...*Route) *Group {
 	g := &Group{router: router}
 
 	for _, r := range routes {
-		g.Add(r)
+		g.add(r)
 		g.calculateRouteRegexs()
 	}
 
@@ -29,17 +54,65 @@ func newGroup(router *Router, routes ...*Route) *Group {
 }
 
 func (g *Group) Prefix(path string) *Group {
-	g.prefix = path
-	g.calculateRouteRegexs()
+	g.router.update(func() {
+		g.prefix = path
+		g.calculateRouteRegexs()
+	})
 	return g
 }
 
 func (g *Group) Middleware(middleware ...Middleware) *Group {
-	g.middleware = middleware
+	g.router.update(func() {
+		g.middleware = middleware
+	})
+	return g
+}
+
+// Timeout limits how long the group's routes may take to respond. See
+// Route.Timeout.
+func (g *Group) Timeout(d time.Duration) *Group {
+	g.router.update(func() {
+		g.timeout = d
+	})
+	return g
+}
+
+// MaxBodySize limits request bodies for the group's routes to n bytes. See
+// Route.MaxBodySize.
+func (g *Group) MaxBodySize(n int64) *Group {
+	g.router.update(func() {
+		g.maxBodySize = n
+	})
+	return g
+}
+
+// ETag enables conditional requests for the group's routes. See Route.ETag.
+func (g *Group) ETag(current ETagFunc) *Group {
+	g.router.update(func() {
+		g.etag = true
+		g.currentETag = current
+	})
+	return g
+}
+
+// Auth requires requests to the group's routes to be authenticated by the named
+// guard. See Route.Auth.
+func (g *Group) Auth(guard string) *Group {
+	g.router.update(func() {
+		g.auth = guard
+	})
 	return g
 }
 
 func (g *Group) Add(routes ...*Route) *Group {
+	g.router.update(func() {
+		g.add(routes...)
+	})
+	return g
+}
+
+// add registers routes on the group. Callers must hold g.router.mu.
+func (g *Group) add(routes ...*Route) {
 	for _, r := range routes {
 		i, found := g.findExistingRoute(r)
 		if found {
@@ -49,9 +122,10 @@ func (g *Group) Add(routes ...*Route) *Group {
 		}
 
 		r.group = g
+		r.router = g.router
+		r.regex = r.calculateRouteRegex()
 		r.buildHandler()
 	}
-	return g
 }
 
 func (g *Group) findExistingRoute(route *Route) (int, bool) {
@@ -65,5 +139,8 @@ func (g *Group) findExistingRoute(route *Route) (int, bool) {
 }
 
 func (g *Group) Routes() []*Route {
-	return g.routes
+	g.router.mu.Lock()
+	defer g.router.mu.Unlock()
+
+	return append([]*Route(nil), g.routes...)
 }
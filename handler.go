@@ -21,6 +21,10 @@ func (r *Router) AddHandlerTransformer(fn interface{}) error {
 	// Get the type of the first (and only) parameter to `fn`. This will be the
 	// signature of any route resolvers that are added to the router.
 	sig := t.In(0).String()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if _, ok := r.transformers[sig]; ok {
 		return fmt.Errorf("handler signature `%s` already exists, transformer not added", sig)
 	}
@@ -30,12 +34,15 @@ func (r *Router) AddHandlerTransformer(fn interface{}) error {
 }
 
 // buildHandler dynamically creates an http.Handler based on the function signature
-// of the passed in function `fn`.
+// of the passed in function `fn`. Callers must hold r.mu.
 func (r *Router) buildHandler(v interface{}) http.Handler {
 	// Retrieve the of the function from the transformer map.
 	t := fmt.Sprintf("%T", v)
 	val, ok := r.transformers[t]
 	if !ok {
+		if handler, ok := bindingHandler(v); ok {
+			return handler
+		}
 		panic("transformer does not exist")
 	}
 	f := reflect.ValueOf(val)
@@ -1,19 +1,36 @@
 package router
 
 import (
+	"io"
 	"net/http"
 )
 
+// defaultContentType is the content type of responses from string-returning
+// handlers when neither the route nor the router sets one.
+const defaultContentType = "text/html; charset=utf-8"
+
 // defaultHandlers define the preconfigured transformers for the Router.
 var defaultHandlers = []interface{}{
 	func(fn func() string) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(fn()))
+			writeString(w, r, fn())
 		})
 	},
 	func(fn func(*http.Request) string) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(fn(r)))
+			writeString(w, r, fn(r))
+		})
+	},
+	func(fn func() (interface{}, error)) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v, err := fn()
+			writeValue(w, r, v, err)
+		})
+	},
+	func(fn func(*http.Request) (interface{}, error)) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v, err := fn(r)
+			writeValue(w, r, v, err)
 		})
 	},
 	func(fn http.HandlerFunc) http.Handler {
@@ -26,3 +43,19 @@ var defaultHandlers = []interface{}{
 		return fn
 	},
 }
+
+// writeString writes s as the response body. Unless the handler has already set
+// one, the Content-Type header is set to the type the matched route produces.
+func writeString(w http.ResponseWriter, r *http.Request, s string) {
+	if w.Header().Get("Content-Type") == "" {
+		contentType := defaultContentType
+		if route := CurrentRoute(r); route != nil && route.produces != "" {
+			contentType = route.produces
+		}
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if _, err := io.WriteString(w, s); err != nil {
+		ReportError(r, err)
+	}
+}
@@ -1,6 +1,7 @@
 package router_test
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -30,3 +31,54 @@ func TestCanRegisterCustomHandlerTransformer(t *testing.T) {
 
 	assert.Equal(t, "99", get(server.URL))
 }
+
+func TestStringHandlersSetContentType(t *testing.T) {
+	r := router.New()
+	r.Get("html", helloHandler)
+	r.Get("text", helloHandler).Produces("text/plain; charset=utf-8")
+	r.Get("custom", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("a,b"))
+	})
+
+	cases := map[string]string{
+		"/html":   "text/html; charset=utf-8",
+		"/text":   "text/plain; charset=utf-8",
+		"/custom": "text/csv",
+	}
+
+	for path, expected := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, rec.Header().Get("Content-Type"), path)
+	}
+
+	r.Produces("application/xml")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/html", nil))
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+}
+
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func (failingWriter) WriteString(string) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestWriteErrorsAreReported(t *testing.T) {
+	var reported error
+	r := router.New().OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	r.Get("/", helloHandler)
+
+	r.ServeHTTP(failingWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.EqualError(t, reported, "connection reset")
+}
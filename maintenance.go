@@ -0,0 +1,142 @@
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maintenanceCookie is the cookie that lets clients bypass maintenance mode.
+const maintenanceCookie = "maintenance_bypass"
+
+// Maintenance configures maintenance mode. See Router.Down.
+type Maintenance struct {
+	// RetryAfter is sent in the Retry-After header, telling clients when to
+	// try again. It defaults to a minute.
+	RetryAfter time.Duration
+
+	// Allow lists the names of routes that are served as usual, such as health
+	// checks.
+	Allow []string
+
+	// Secret lets clients bypass maintenance mode. Visiting /{Secret} sets a
+	// cookie that bypasses maintenance mode until the router is taken down with
+	// a different secret, then redirects to /.
+	Secret string
+
+	// Render writes the maintenance page, and should respond with a 503. By
+	// default, a plain 503 response is sent.
+	Render http.Handler
+}
+
+// maintenance is the state of a router that is down for maintenance.
+type maintenance struct {
+	Maintenance
+	allowed map[string]bool
+	// token is the value of the bypass cookie.
+	token string
+}
+
+// Down puts the router into maintenance mode. Requests receive a 503 response
+// with a Retry-After header, except requests to allowed routes and requests from
+// clients that have visited the secret URL. Down can be called while the router
+// is serving, and takes effect for the next request.
+func (router *Router) Down(m Maintenance) *Router {
+	if m.RetryAfter <= 0 {
+		m.RetryAfter = time.Minute
+	}
+
+	state := &maintenance{Maintenance: m, allowed: map[string]bool{}}
+	state.Allow = append([]string(nil), m.Allow...)
+	for _, name := range m.Allow {
+		state.allowed[name] = true
+	}
+	if m.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(m.Secret))
+		mac.Write([]byte(maintenanceCookie))
+		state.token = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	router.update(func() {
+		router.maintenance = state
+	})
+	return router
+}
+
+// Up takes the router out of maintenance mode.
+func (router *Router) Up() *Router {
+	router.update(func() {
+		router.maintenance = nil
+	})
+	return router
+}
+
+// IsDown determines whether the router is in maintenance mode.
+func (router *Router) IsDown() bool {
+	return router.routes().maintenance != nil
+}
+
+// bypass handles requests for the secret URL, setting the bypass cookie and
+// redirecting to /. It returns false for any other request, or if the router
+// isn't down.
+func (m *maintenance) bypass(w http.ResponseWriter, r *http.Request) bool {
+	if m == nil || m.Secret == "" || r.URL.Path != "/"+m.Secret {
+		return false
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     maintenanceCookie,
+		Value:    m.token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusFound)
+
+	return true
+}
+
+// allows determines whether a request is served while the router is down. err is
+// the error from finding the request's route.
+func (m *maintenance) allows(r *http.Request, route *Route, err error) bool {
+	if err == nil && route.name != "" && m.allowed[route.name] {
+		return true
+	}
+
+	if m.token == "" {
+		return false
+	}
+
+	cookie, cerr := r.Cookie(maintenanceCookie)
+	return cerr == nil && hmac.Equal([]byte(cookie.Value), []byte(m.token))
+}
+
+// refuses determines whether the request is refused because the router is down
+// for maintenance, and if so, writes the maintenance response. A nil
+// maintenance refuses nothing.
+func (m *maintenance) refuses(
+	w http.ResponseWriter, r *http.Request, route *Route, err error,
+) bool {
+	if m == nil || m.allows(r, route, err) {
+		return false
+	}
+
+	m.render(w, r)
+	return true
+}
+
+func (m *maintenance) render(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(m.RetryAfter.Round(time.Second)/time.Second)))
+
+	if m.Render != nil {
+		m.Render.ServeHTTP(w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte("503 service unavailable"))
+}
@@ -0,0 +1,80 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownAndUp(t *testing.T) {
+	r := router.New()
+	r.Get("/", helloHandler)
+	r.Get("health", func() string { return "ok" }).Name("health")
+	assert.False(t, r.IsDown())
+
+	r.Down(router.Maintenance{Allow: []string{"health"}, RetryAfter: 5 * time.Minute})
+	assert.True(t, r.IsDown())
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "300", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "503 service unavailable", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	r.Up()
+	assert.False(t, r.IsDown())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMaintenanceBypass(t *testing.T) {
+	r := router.New()
+	r.Get("/", helloHandler)
+	r.Down(router.Maintenance{Secret: "let-me-in"})
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/let-me-in", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/", rec.Header().Get("Location"))
+
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.NotContains(t, cookies[0].Value, "let-me-in")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rec = record(r, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	r.Down(router.Maintenance{Secret: "new-secret"})
+	rec = record(r, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestMaintenancePage(t *testing.T) {
+	r := router.New()
+	r.Get("/", helloHandler)
+	r.Down(router.Maintenance{
+		Render: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<h1>Back soon</h1>"))
+		}),
+	})
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "<h1>Back soon</h1>", rec.Body.String())
+}
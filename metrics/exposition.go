@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ServeHTTP writes the collected metrics in the Prometheus text exposition
+// format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+
+	b := bufio.NewWriter(w)
+	c.write(b)
+	b.Flush()
+}
+
+func (c *Collector) write(b *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]labels, 0, len(c.series))
+	for l := range c.series {
+		keys = append(keys, l)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	routes := make([]route, 0, len(c.inFlight))
+	for rt := range c.inFlight {
+		routes = append(routes, rt)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].String() < routes[j].String()
+	})
+
+	header(b, "http_requests_total", "counter", "Total number of HTTP requests served.")
+	for _, l := range keys {
+		fmt.Fprintf(b, "http_requests_total{%s} %d\n", l, c.series[l].requests)
+	}
+
+	header(b, "http_requests_in_flight", "gauge", "Number of HTTP requests currently being served.")
+	for _, rt := range routes {
+		fmt.Fprintf(b, "http_requests_in_flight{%s} %d\n", rt, c.inFlight[rt])
+	}
+
+	header(b, "http_request_duration_seconds", "histogram", "Time taken to serve HTTP requests.")
+	for _, l := range keys {
+		writeHistogram(b, "http_request_duration_seconds", l, &c.series[l].duration, c.durationBuckets)
+	}
+
+	header(b, "http_response_size_bytes", "histogram", "Size of HTTP response bodies.")
+	for _, l := range keys {
+		writeHistogram(b, "http_response_size_bytes", l, &c.series[l].size, c.sizeBuckets)
+	}
+}
+
+func header(b *bufio.Writer, name string, kind string, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeHistogram(b *bufio.Writer, name string, l labels, h *histogram, bounds []float64) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, l, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, l, h.count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, l, formatFloat(h.sum))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, l, h.count)
+}
+
+func (rt route) String() string {
+	return `method="` + escape(rt.method) + `",route="` + escape(rt.route) + `"`
+}
+
+func (l labels) String() string {
+	return l.route.String() + `,status="` + escape(l.status) + `"`
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape escapes a label value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
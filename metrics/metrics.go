@@ -0,0 +1,191 @@
+// Package metrics records request metrics for the router and serves them in the
+// Prometheus text exposition format. Metrics are labelled by the route pattern
+// or name rather than the request path, so that route parameters don't create a
+// new series for every value:
+//
+//	m := metrics.New(metrics.Config{})
+//	r.Middleware(m.Middleware())
+//	r.Get("metrics", m.ServeHTTP)
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gostalt/router"
+)
+
+// DefaultDurationBuckets are the upper bounds in seconds of the latency
+// histogram's buckets, unless Config sets others.
+var DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the upper bounds in bytes of the response size
+// histogram's buckets, unless Config sets others.
+var DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+
+// Config configures a Collector.
+type Config struct {
+	// DurationBuckets and SizeBuckets are the upper bounds of the histogram
+	// buckets, in ascending order.
+	DurationBuckets []float64
+	SizeBuckets     []float64
+}
+
+// Collector records metrics for the requests served by its middleware. It is an
+// http.Handler that serves the metrics, which can be added to a router with its
+// ServeHTTP method.
+type Collector struct {
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	mu       sync.Mutex
+	series   map[labels]*series
+	inFlight map[route]int64
+}
+
+// route identifies the requests counted by the in-flight gauge.
+type route struct {
+	method string
+	route  string
+}
+
+// labels identify the series of a completed request.
+type labels struct {
+	route
+	status string
+}
+
+type series struct {
+	requests uint64
+	duration histogram
+	size     histogram
+}
+
+type histogram struct {
+	// counts holds the number of observations in each bucket, excluding
+	// those in lower buckets.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64, bounds []float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds))
+	}
+
+	if i := sort.SearchFloat64s(bounds, v); i < len(bounds) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// New creates a Collector.
+func New(c Config) *Collector {
+	if c.DurationBuckets == nil {
+		c.DurationBuckets = DefaultDurationBuckets
+	}
+	if c.SizeBuckets == nil {
+		c.SizeBuckets = DefaultSizeBuckets
+	}
+	if !sort.Float64sAreSorted(c.DurationBuckets) || !sort.Float64sAreSorted(c.SizeBuckets) {
+		panic("metrics: buckets must be in ascending order")
+	}
+
+	return &Collector{
+		durationBuckets: append([]float64(nil), c.DurationBuckets...),
+		sizeBuckets:     append([]float64(nil), c.SizeBuckets...),
+		series:          map[labels]*series{},
+		inFlight:        map[route]int64{},
+	}
+}
+
+// Middleware returns middleware that records metrics for each request. Requests
+// are labelled with the name of the route they matched, or its pattern if it
+// has no name. Requests passed to a mounted router are labelled with the route
+// the mounted router matched, including the mount prefix in its pattern.
+func (c *Collector) Middleware() router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rt := route{method: r.Method, route: routeLabel(r)}
+			rw := router.WrapResponseWriter(w)
+			start := time.Now()
+
+			c.mu.Lock()
+			c.inFlight[rt]++
+			c.mu.Unlock()
+
+			defer func() {
+				v := recover()
+
+				status := rw.Status()
+				if v != nil && status == 0 {
+					status = http.StatusInternalServerError
+				} else if status == 0 {
+					status = http.StatusOK
+				}
+
+				// Mounted routers have matched their own route by now, which
+				// is more specific than the route the request started on.
+				l := labels{
+					route:  route{method: r.Method, route: routeLabel(r)},
+					status: statusClass(status),
+				}
+				c.observe(rt, l, time.Since(start), rw.BytesWritten())
+
+				if v != nil {
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// observe records a completed request, which was counted as in flight for the
+// route `started`.
+func (c *Collector) observe(started route, l labels, duration time.Duration, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight[started]--
+
+	s, ok := c.series[l]
+	if !ok {
+		s = &series{}
+		c.series[l] = s
+	}
+
+	s.requests++
+	s.duration.observe(duration.Seconds(), c.durationBuckets)
+	s.size.observe(float64(size), c.sizeBuckets)
+}
+
+// routeLabel returns the label for the route serving the request, looking
+// through any routers it is mounted beneath.
+func routeLabel(r *http.Request) string {
+	rt, pattern := router.ServedRoute(r)
+	if rt == nil {
+		return ""
+	}
+
+	if name := rt.GetName(); name != "" {
+		return name
+	}
+
+	return pattern
+}
+
+// statusClass groups a status code by its first digit, such as "2xx".
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,130 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/gostalt/router/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(h http.Handler) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func serve(h http.Handler, method string, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New(metrics.Config{SizeBuckets: []float64{1, 10}})
+	r := router.New()
+	r.Middleware(m.Middleware())
+	r.Get("users/{id}", func() string { return "ok" })
+	r.Get("missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}).Name("missing")
+
+	serve(r, http.MethodGet, "/users/1")
+	serve(r, http.MethodGet, "/users/2")
+	serve(r, http.MethodGet, "/missing")
+
+	body := scrape(m)
+
+	users := `method="GET",route="/users/{id}",status="2xx"`
+	assert.Contains(t, body, "# TYPE http_requests_total counter\n")
+	assert.Contains(t, body, `http_requests_total{`+users+`} 2`+"\n")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="missing",status="4xx"} 1`+"\n")
+
+	assert.Contains(t, body, "# TYPE http_requests_in_flight gauge\n")
+	assert.Contains(t, body, `http_requests_in_flight{method="GET",route="/users/{id}"} 0`+"\n")
+
+	assert.Contains(t, body, "# TYPE http_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{`+users+`,le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, `http_request_duration_seconds_count{`+users+`} 2`+"\n")
+
+	// "ok" is 2 bytes, so falls in the second bucket.
+	assert.Contains(t, body, `http_response_size_bytes_bucket{`+users+`,le="1"} 0`+"\n")
+	assert.Contains(t, body, `http_response_size_bytes_bucket{`+users+`,le="10"} 2`+"\n")
+	assert.Contains(t, body, `http_response_size_bytes_sum{`+users+`} 4`+"\n")
+}
+
+func TestMountedRoutes(t *testing.T) {
+	billing := router.New()
+	billing.Get("invoices/{id}", func() string { return "ok" })
+
+	m := metrics.New(metrics.Config{})
+	r := router.New()
+	r.Middleware(m.Middleware())
+	r.Mount("/tenants/{tenant}/billing", billing)
+	r.Mount("/static", http.NotFoundHandler())
+
+	serve(r, http.MethodGet, "/tenants/acme/billing/invoices/1")
+	serve(r, http.MethodGet, "/static/app.css")
+
+	body := scrape(m)
+	assert.Contains(t, body, `http_requests_total{method="GET",`+
+		`route="/tenants/{tenant}/billing/invoices/{id}",status="2xx"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/static",status="4xx"} 1`)
+	assert.Contains(t, body,
+		`http_requests_in_flight{method="GET",route="/tenants/{tenant}/billing"} 0`)
+	assert.NotContains(t, body, "_mount")
+}
+
+func TestInFlight(t *testing.T) {
+	m := metrics.New(metrics.Config{})
+	r := router.New()
+	r.Middleware(m.Middleware())
+	r.Get("slow", func() string {
+		assert.Contains(t, scrape(m), `http_requests_in_flight{method="GET",route="/slow"} 1`)
+		return "ok"
+	})
+
+	serve(r, http.MethodGet, "/slow")
+	assert.Contains(t, scrape(m), `http_requests_in_flight{method="GET",route="/slow"} 0`)
+}
+
+func TestPanicsAreCountedAsServerErrors(t *testing.T) {
+	m := metrics.New(metrics.Config{})
+	h := m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	assert.Panics(t, func() { serve(h, http.MethodGet, "/") })
+	assert.Contains(t, scrape(m), `http_requests_total{method="GET",route="",status="5xx"} 1`)
+}
+
+func TestExpositionFormat(t *testing.T) {
+	m := metrics.New(metrics.Config{})
+	r := router.New()
+	r.Middleware(m.Middleware())
+	r.Get("quote", func() string { return "ok" }).Name(`say "hi"\`)
+	r.Get("metrics", m.ServeHTTP)
+
+	serve(r, http.MethodGet, "/quote")
+
+	rec := serve(r, http.MethodGet, "/metrics")
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `route="say \"hi\"\\"`)
+
+	// Every sample line is a metric name, optional labels and a value.
+	for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		assert.Len(t, strings.Fields(line[strings.LastIndex(line, "}")+1:]), 1, line)
+	}
+}
+
+func TestBucketsMustBeSorted(t *testing.T) {
+	assert.Panics(t, func() {
+		metrics.New(metrics.Config{DurationBuckets: []float64{1, 0.5}})
+	})
+}
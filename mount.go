@@ -0,0 +1,157 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// mountParam is the name of the catch-all parameter that captures the part of
+// the path beneath a mount point.
+const mountParam = "_mount"
+
+// mountMethods are the HTTP verbs forwarded to a mounted handler.
+var mountMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// Mount forwards every request beneath `prefix` to the given handler, with the
+// prefix stripped from the request path. The prefix may contain parameters,
+// which are available to the handler alongside any of its own.
+//
+// The router's middleware wraps the mounted handler. If the handler is itself a
+// *Router, its middleware runs next, and its routes are included in Routes.
+// Routes defined on this router beneath the prefix take precedence over the
+// mounted handler.
+func (router *Router) Mount(prefix string, handler http.Handler) *Route {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	strip := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, _ := r.Context().Value(mountPathKey).(string)
+		m := &mountPoint{
+			prefix:  mountPrefix(r) + strings.TrimSuffix(strings.TrimSuffix(r.URL.Path, rest), "/"),
+			pattern: mountPattern(r) + CurrentRoute(r).mountPattern(),
+			parent:  router,
+			up:      mountOf(r),
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), mountKey, m))
+		handler.ServeHTTP(w, withPath(r, "/"+rest))
+	})
+
+	route := router.addRoute(mountMethods, prefix+"/{"+mountParam+"...?}", strip)
+	route.modify(func() {
+		route.mount = handler
+	})
+
+	return route
+}
+
+// mountPoint records where the router serving a request is mounted.
+type mountPoint struct {
+	// prefix is the part of the request's original path that was stripped
+	// before the request reached the mounted handler, and pattern is the part
+	// of the route patterns it was matched by.
+	prefix  string
+	pattern string
+	// parent is the router the handler is mounted on, and up is where the
+	// parent is mounted, if anywhere.
+	parent *Router
+	up     *mountPoint
+}
+
+// mountOf returns where the router serving the request is mounted, or nil if
+// it isn't mounted.
+func mountOf(r *http.Request) *mountPoint {
+	m, _ := r.Context().Value(mountKey).(*mountPoint)
+	return m
+}
+
+// mountPrefix returns the part of the request's original path that was stripped
+// by the routers it was mounted beneath. Paths on the router serving the request
+// must be joined to it to form URLs the client can use.
+func mountPrefix(r *http.Request) string {
+	if m := mountOf(r); m != nil {
+		return m.prefix
+	}
+
+	return ""
+}
+
+// mountPattern returns the pattern of the mount points the request was passed
+// through, which prefixes the patterns of routes on the router serving it.
+func mountPattern(r *http.Request) string {
+	if m := mountOf(r); m != nil {
+		return m.pattern
+	}
+
+	return ""
+}
+
+// mountPattern returns the route's pattern without the parameter that captures
+// the path beneath a mount point.
+func (route *Route) mountPattern() string {
+	return strings.TrimSuffix(route.pattern, "/{"+mountParam+"...?}")
+}
+
+// mountsRouter determines whether the route is the mount point of a *Router,
+// which matches requests to routes of its own.
+func (route *Route) mountsRouter() bool {
+	_, ok := route.mount.(*Router)
+	return ok
+}
+
+// withMountPath returns a shallow copy of the request with the path beneath the
+// mount point attached to its context.
+func withMountPath(r *http.Request, rest string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), mountPathKey, rest))
+}
+
+// Routes returns the routes registered on the router, in order of precedence.
+// Routes on a mounted *Router are included with the mount prefix added to
+// their patterns.
+func (router *Router) Routes() []*Route {
+	var routes []*Route
+	for _, route := range router.routes().routes {
+		sub, ok := route.mount.(*Router)
+		if !ok {
+			routes = append(routes, route)
+			continue
+		}
+
+		for _, r := range sub.Routes() {
+			routes = append(routes, r.mountedAt(route))
+		}
+	}
+
+	return routes
+}
+
+// mountedAt returns a copy of a route on a mounted router as seen from the
+// router it is mounted on, with the mount's prefix added to its pattern and
+// the mount's guard and abilities applied.
+func (route *Route) mountedAt(mount *Route) *Route {
+	mounted := *route
+	mounted.pattern = mount.mountPattern() + route.pattern
+	if segs, err := parsePattern(mounted.pattern); err == nil {
+		mounted.versionAt += len(segs) - len(route.segments)
+		mounted.segments = segs
+		mounted.params = params(segs)
+		mounted.regex = compilePattern(segs)
+	}
+
+	if mounted.auth == "" {
+		mounted.auth = mount.auth
+	}
+	mounted.abilities = append(append([]ability(nil), mount.abilities...), route.abilities...)
+
+	return &mounted
+}
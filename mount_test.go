@@ -0,0 +1,122 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountSubRouter(t *testing.T) {
+	billing := router.New()
+	billing.Middleware(twoMiddleware)
+	billing.Get("/", func() string { return "index" })
+	billing.Get("invoices/{id}", func(r *http.Request) string {
+		return router.Param(r, "tenant") + " " + router.Param(r, "id") + " " + r.URL.Path
+	})
+
+	rtr := router.New()
+	rtr.Middleware(oneMiddleware)
+	rtr.Mount("/tenants/{tenant}/billing", billing)
+	rtr.Get("/tenants/{tenant}/billing/status", func() string { return "status" })
+
+	server := httptest.NewServer(rtr)
+	defer server.Close()
+
+	assert.Equal(t, "12acme 42 /invoices/42", get(server.URL+"/tenants/acme/billing/invoices/42"))
+	assert.Equal(t, "12index", get(server.URL+"/tenants/acme/billing"))
+	assert.Equal(t, "12index", get(server.URL+"/tenants/acme/billing/"))
+	assert.Equal(t, "1status", get(server.URL+"/tenants/acme/billing/status"))
+	assert.Equal(t, "1404 not found", get(server.URL+"/tenants/acme/billing/missing"))
+}
+
+func TestMountHandler(t *testing.T) {
+	rtr := router.New()
+	rtr.Mount("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+
+	rec := httptest.NewRecorder()
+	rtr.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/static/css/app.css?v=2", nil))
+
+	assert.Equal(t, "POST /css/app.css", rec.Body.String())
+}
+
+func TestRoutesIncludesMountedRoutes(t *testing.T) {
+	billing := router.New()
+	billing.Get("invoices", helloHandler)
+
+	rtr := router.New()
+	rtr.Get("home", helloHandler)
+	rtr.Mount("/billing", billing)
+
+	var patterns []string
+	for _, r := range rtr.Routes() {
+		patterns = append(patterns, r.Pattern())
+	}
+
+	assert.Equal(t, []string{"/home", "/billing/invoices"}, patterns)
+}
+
+func TestMountedRedirectsKeepPrefix(t *testing.T) {
+	billing := router.New().TrailingSlash(router.RedirectSlash).CleanPath()
+	billing.Get("invoices", helloHandler)
+
+	rtr := router.New()
+	rtr.Mount("/tenants/{tenant}/billing", billing)
+
+	paths := []string{"/tenants/acme/billing/invoices/", "/tenants/acme/billing//invoices"}
+	for _, path := range paths {
+		rec := httptest.NewRecorder()
+		rtr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?page=2", nil))
+
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code, path)
+		assert.Equal(t, "/tenants/acme/billing/invoices?page=2", rec.Header().Get("Location"), path)
+	}
+}
+
+func TestMountedRouteURLs(t *testing.T) {
+	billing := router.New()
+	billing.Get("invoices/{id}", func(r *http.Request) string {
+		invoice, _ := router.URLFor(r, "invoice", map[string]string{"id": "7"})
+		home, _ := router.URLFor(r, "home", nil)
+		return invoice + " " + home
+	}).Name("invoice")
+
+	rtr := router.New()
+	rtr.Get("home", helloHandler).Name("home")
+	rtr.Mount("/tenants/{tenant}/billing", billing)
+
+	u, err := rtr.URL("invoice", map[string]string{"tenant": "acme", "id": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/tenants/acme/billing/invoices/1", u)
+
+	rec := httptest.NewRecorder()
+	rtr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/acme/billing/invoices/1", nil))
+
+	assert.Equal(t, "/tenants/acme/billing/invoices/7 /home", rec.Body.String())
+}
+
+func TestServedRouteSeesThroughMounts(t *testing.T) {
+	var before, after string
+	billing := router.New()
+	billing.Get("invoices/{id}", helloHandler).Name("invoice")
+
+	rtr := router.New()
+	rtr.Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, before = router.ServedRoute(r)
+			next.ServeHTTP(w, r)
+			_, after = router.ServedRoute(r)
+		})
+	})
+	rtr.Mount("/tenants/{tenant}/billing", billing)
+
+	req := httptest.NewRequest(http.MethodGet, "/tenants/acme/billing/invoices/1", nil)
+	rtr.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "/tenants/{tenant}/billing", before)
+	assert.Equal(t, "/tenants/{tenant}/billing/invoices/{id}", after)
+}
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SlashPolicy determines how the router treats a trailing slash on a request
+// path that doesn't match a route as-is.
+type SlashPolicy int
+
+const (
+	// StrictSlash treats paths with and without a trailing slash as different
+	// paths. This is the default policy.
+	StrictSlash SlashPolicy = iota
+	// RedirectSlash redirects requests to the form of the path that a route is
+	// defined with. GET and HEAD requests are redirected with a 301, and other
+	// requests with a 308 so that their method and body are preserved.
+	RedirectSlash
+	// MatchSlash serves requests for either form of the path from the route,
+	// without redirecting.
+	MatchSlash
+)
+
+// TrailingSlash sets the policy the router uses for trailing slashes.
+func (router *Router) TrailingSlash(policy SlashPolicy) *Router {
+	router.update(func() {
+		router.slashes = policy
+	})
+	return router
+}
+
+// CleanPath enables path cleaning on the router. Repeated slashes and `.` and
+// `..` elements are removed from the request path before it is matched. GET
+// and HEAD requests for an unclean path are redirected to the clean path with
+// a 301, while other requests are served as if the clean path was requested.
+func (router *Router) CleanPath() *Router {
+	router.update(func() {
+		router.clean = true
+	})
+	return router
+}
+
+// CaseInsensitive makes the router match request paths to routes regardless of
+// case. Parameter values are passed to the handler in their original case.
+func (router *Router) CaseInsensitive() *Router {
+	router.update(func() {
+		router.caseInsensitive = true
+	})
+	return router
+}
+
+// cleanPath returns the canonical form of p, keeping any trailing slash.
+func cleanPath(p string) string {
+	if p == "" || p[0] != '/' {
+		p = "/" + p
+	}
+
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// toggleSlash adds a trailing slash to p, or removes it if it already has one.
+func toggleSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return strings.TrimSuffix(p, "/")
+	}
+
+	return p + "/"
+}
+
+// caseless returns a case-insensitive copy of rx.
+func caseless(rx *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + rx.String())
+}
+
+// withPath returns a shallow copy of the request with its URL path replaced.
+func withPath(r *http.Request, p string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+
+	u := *r.URL
+	u.Path, u.RawPath = p, ""
+	r2.URL = &u
+
+	return r2
+}
+
+// redirectTo redirects the request to the path p, keeping its query string. If
+// the router is mounted, p is relative to the mount point.
+func redirectTo(w http.ResponseWriter, r *http.Request, p string, code int) {
+	u := url.URL{Path: mountPrefix(r) + p, RawQuery: r.URL.RawQuery}
+	http.Redirect(w, r, u.String(), code)
+}
+
+// isSafeMethod determines whether the request can be redirected with a 301
+// without the client changing its method.
+func isSafeMethod(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// resolve finds the route for the request, applying the router's path cleaning
+// and trailing slash policies. If the request should be redirected, the route
+// is nil and the redirect has already been written to w. The returned request
+// is the one the route should be served, which may have a rewritten path.
+func (router *Router) resolve(
+	t *table, w http.ResponseWriter, r *http.Request,
+) (*Route, *http.Request, error) {
+	if t.clean {
+		if p := cleanPath(r.URL.Path); p != r.URL.Path {
+			if isSafeMethod(r) {
+				redirectTo(w, r, p, http.StatusMovedPermanently)
+				return nil, r, nil
+			}
+			r = withPath(r, p)
+		}
+	}
+
+	route, req, err := router.findRoute(t, r)
+	if err == nil || t.slashes == StrictSlash || r.URL.Path == "/" {
+		return route, req, err
+	}
+
+	alt := withPath(r, toggleSlash(r.URL.Path))
+	route, altReq, altErr := router.findRoute(t, alt)
+	if altErr != nil {
+		return route, r, err
+	}
+
+	if t.slashes == MatchSlash {
+		return route, altReq, nil
+	}
+
+	code := http.StatusPermanentRedirect
+	if isSafeMethod(r) {
+		code = http.StatusMovedPermanently
+	}
+	redirectTo(w, r, alt.URL.Path, code)
+
+	return nil, r, nil
+}
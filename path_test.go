@@ -0,0 +1,118 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathPolicies(t *testing.T) {
+	type testcase struct {
+		setup    func(r *router.Router)
+		method   string
+		path     string
+		status   int
+		location string
+		body     string
+	}
+
+	cases := map[string]testcase{
+		"query string is ignored when matching": {
+			setup:  func(r *router.Router) {},
+			method: http.MethodGet,
+			path:   "/users?page=2",
+			status: http.StatusOK,
+			body:   "users",
+		},
+		"trailing slash is strict by default": {
+			setup:  func(r *router.Router) {},
+			method: http.MethodGet,
+			path:   "/users/",
+			status: http.StatusNotFound,
+		},
+		"trailing slash redirects GET with 301": {
+			setup:    func(r *router.Router) { r.TrailingSlash(router.RedirectSlash) },
+			method:   http.MethodGet,
+			path:     "/users/?page=2",
+			status:   http.StatusMovedPermanently,
+			location: "/users?page=2",
+		},
+		"trailing slash redirects POST with 308": {
+			setup:    func(r *router.Router) { r.TrailingSlash(router.RedirectSlash) },
+			method:   http.MethodPost,
+			path:     "/users/",
+			status:   http.StatusPermanentRedirect,
+			location: "/users",
+		},
+		"trailing slash is added when the route has one": {
+			setup:    func(r *router.Router) { r.TrailingSlash(router.RedirectSlash) },
+			method:   http.MethodGet,
+			path:     "/docs",
+			status:   http.StatusMovedPermanently,
+			location: "/docs/",
+		},
+		"trailing slash matches both forms": {
+			setup:  func(r *router.Router) { r.TrailingSlash(router.MatchSlash) },
+			method: http.MethodGet,
+			path:   "/users/",
+			status: http.StatusOK,
+			body:   "users",
+		},
+		"unclean paths don't match by default": {
+			setup:  func(r *router.Router) {},
+			method: http.MethodGet,
+			path:   "//users",
+			status: http.StatusNotFound,
+		},
+		"unclean GET is redirected": {
+			setup:    func(r *router.Router) { r.CleanPath() },
+			method:   http.MethodGet,
+			path:     "/a/../users",
+			status:   http.StatusMovedPermanently,
+			location: "/users",
+		},
+		"unclean POST is rewritten": {
+			setup:  func(r *router.Router) { r.CleanPath() },
+			method: http.MethodPost,
+			path:   "//users",
+			status: http.StatusOK,
+			body:   "created",
+		},
+		"paths are case sensitive by default": {
+			setup:  func(r *router.Router) {},
+			method: http.MethodGet,
+			path:   "/USERS",
+			status: http.StatusNotFound,
+		},
+		"case insensitive matching keeps parameter case": {
+			setup:  func(r *router.Router) { r.CaseInsensitive() },
+			method: http.MethodGet,
+			path:   "/Users/Bob",
+			status: http.StatusOK,
+			body:   "Bob",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := router.New()
+			r.Get("users", func() string { return "users" })
+			r.Post("users", func() string { return "created" })
+			r.Get("users/{name}", func(req *http.Request) string { return router.Param(req, "name") })
+			r.Get("docs/", func() string { return "docs" })
+			tc.setup(r)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.location, rec.Header().Get("Location"))
+			if tc.body != "" {
+				assert.Equal(t, tc.body, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+)
+
+// Policy decides whether a request may perform an ability. model is the model
+// bound to the route parameter named in Route.Can, or nil if no parameter was
+// named. Policies typically check the principal authenticated by the route's
+// guard.
+type Policy func(r *http.Request, model interface{}) (bool, error)
+
+// ModelFunc loads the model that a route parameter's value refers to, such as a
+// post from its ID. It returns nil if there is no such model.
+type ModelFunc func(r *http.Request, value string) (interface{}, error)
+
+// ability is an ability that a route requires, along with the parameter whose
+// model the ability is checked against, if any.
+type ability struct {
+	name  string
+	param string
+}
+
+func (a ability) String() string {
+	if a.param == "" {
+		return a.name
+	}
+
+	return a.name + "({" + a.param + "})"
+}
+
+// Policy registers the policy that decides whether requests may perform an
+// ability. Routes and groups require abilities with Route.Can and Group.Can.
+func (router *Router) Policy(ability string, policy Policy) *Router {
+	router.update(func() {
+		if router.policies == nil {
+			router.policies = map[string]Policy{}
+		}
+		router.policies[ability] = policy
+	})
+	return router
+}
+
+// BindModel registers the function that loads models for route parameters with
+// the given name. Models are passed to the policies of abilities checked against
+// the parameter, and handlers can read them with Model. Without a ModelFunc,
+// policies are passed the parameter's value as a string.
+func (router *Router) BindModel(param string, fn ModelFunc) *Router {
+	router.update(func() {
+		if router.models == nil {
+			router.models = map[string]ModelFunc{}
+		}
+		router.models[param] = fn
+	})
+	return router
+}
+
+// Model returns the model bound to the named route parameter while checking the
+// route's abilities, or nil if there is none.
+func Model(r *http.Request, param string) interface{} {
+	models, _ := r.Context().Value(modelsKey).(map[string]interface{})
+	return models[param]
+}
+
+// Can requires requests to the route to be allowed to perform the ability by its
+// policy, registered with Router.Policy. If a parameter is named, its model is
+// loaded and passed to the policy, and requests for models that don't exist
+// receive a 404. Requests that aren't allowed receive a 403.
+//
+// Abilities are checked after the route's guard has authenticated the request,
+// and before the route's own middleware runs.
+func (route *Route) Can(ability string, param ...string) *Route {
+	route.modify(func() {
+		route.abilities = append(route.abilities, newAbility(ability, param))
+	})
+	return route
+}
+
+// Abilities returns the abilities that requests to the route must be allowed to
+// perform, including those required by its group.
+func (route *Route) Abilities() []string {
+	var names []string
+	for _, a := range route.requiredAbilities() {
+		names = append(names, a.String())
+	}
+
+	return names
+}
+
+// GetAuth returns the name of the guard that authenticates requests to the
+// route, including one set on its group, or an empty string if there is none.
+func (route *Route) GetAuth() string {
+	if route.auth == "" && route.group != nil {
+		return route.group.auth
+	}
+
+	return route.auth
+}
+
+// Can requires requests to the group's routes to be allowed to perform the
+// ability. See Route.Can.
+func (g *Group) Can(ability string, param ...string) *Group {
+	g.router.update(func() {
+		g.abilities = append(g.abilities, newAbility(ability, param))
+	})
+	return g
+}
+
+func newAbility(name string, param []string) ability {
+	a := ability{name: name}
+	if len(param) > 0 {
+		a.param = param[0]
+	}
+
+	return a
+}
+
+// requiredAbilities returns the abilities required by the route's group,
+// followed by those required by the route itself.
+func (route *Route) requiredAbilities() []ability {
+	// Frozen routes already hold their group's abilities.
+	if route.served != nil {
+		return route.abilities
+	}
+
+	var abilities []ability
+	if route.group != nil {
+		abilities = append(abilities, route.group.abilities...)
+	}
+
+	return append(abilities, route.abilities...)
+}
+
+// authorizer returns middleware that checks the route's abilities, or nil if it
+// doesn't require any. Callers must hold router.mu.
+func (route *Route) authorizer() Middleware {
+	abilities := route.requiredAbilities()
+	if len(abilities) == 0 {
+		return nil
+	}
+
+	policies := map[string]Policy{}
+	models := map[string]ModelFunc{}
+	if route.router != nil {
+		for _, a := range abilities {
+			if policy, ok := route.router.policies[a.name]; ok {
+				policies[a.name] = policy
+			}
+			if fn, ok := route.router.models[a.param]; ok && a.param != "" {
+				models[a.param] = fn
+			}
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bound := map[string]interface{}{}
+			for _, a := range abilities {
+				policy, ok := policies[a.name]
+				if !ok {
+					ServerError(w, r, fmt.Errorf("router: no policy for ability %q", a.name))
+					return
+				}
+
+				var model interface{}
+				if a.param != "" {
+					var err error
+					if model, err = bindModel(r, a.param, models[a.param], bound); err != nil {
+						ServerError(w, r, err)
+						return
+					}
+					if model == nil {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte("404 not found"))
+						return
+					}
+				}
+
+				allowed, err := policy(r, model)
+				if err != nil {
+					ServerError(w, r, err)
+					return
+				}
+				if !allowed {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte("403 forbidden"))
+					return
+				}
+			}
+
+			if len(bound) > 0 {
+				r = r.WithContext(context.WithValue(r.Context(), modelsKey, bound))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bindModel returns the model for a route parameter, loading it with fn unless
+// it is already in bound. Without a ModelFunc, the model is the parameter's
+// value.
+func bindModel(
+	r *http.Request, param string, fn ModelFunc, bound map[string]interface{},
+) (interface{}, error) {
+	if model, ok := bound[param]; ok {
+		return model, nil
+	}
+
+	value, ok := Params(r)[param]
+	if !ok {
+		return nil, errors.New("router: no route parameter named " + param)
+	}
+
+	var model interface{} = value
+	if fn != nil {
+		var err error
+		if model, err = fn(r, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if model != nil {
+		bound[param] = model
+	}
+
+	return model, nil
+}
+
+// Audit writes a table of the router's routes to w, listing the guard and the
+// abilities each route requires, for reviewing the application's access
+// control.
+func (router *Router) Audit(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHODS\tPATTERN\tNAME\tGUARD\tABILITIES")
+
+	for _, route := range router.Routes() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			strings.Join(route.methods, ","),
+			route.pattern,
+			orDash(route.name),
+			orDash(route.auth),
+			orDash(strings.Join(route.Abilities(), ", ")),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,118 @@
+package router_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+type article struct {
+	ID     string
+	Author string
+}
+
+func TestRouteCan(t *testing.T) {
+	posts := map[string]*article{"1": {ID: "1", Author: "frank"}}
+
+	r := router.New().
+		Policy("posts.update", func(req *http.Request, model interface{}) (bool, error) {
+			return model.(*article).Author == req.Header.Get("X-User"), nil
+		}).
+		BindModel("post", func(req *http.Request, id string) (interface{}, error) {
+			if p, ok := posts[id]; ok {
+				return p, nil
+			}
+			return nil, nil
+		})
+	r.Put("posts/{post}", func(req *http.Request) string {
+		return "updated " + router.Model(req, "post").(*article).ID
+	}).Can("posts.update", "post")
+
+	rec := record(r, httptest.NewRequest(http.MethodPut, "/posts/1", nil), "X-User", "frank")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "updated 1", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/1", nil), "X-User", "mallory")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "403 forbidden", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodPut, "/posts/2", nil), "X-User", "frank")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGroupCan(t *testing.T) {
+	r := router.New().
+		Policy("staff", func(req *http.Request, _ interface{}) (bool, error) {
+			return req.Header.Get("X-Staff") == "yes", nil
+		}).
+		Policy("tenant.member", func(req *http.Request, model interface{}) (bool, error) {
+			return model.(string) == "acme", nil
+		})
+	r.Group(
+		router.Get("admin", func() string { return "admin" }),
+		router.Get("tenants/{tenant}/billing", func() string {
+			return "billing"
+		}).Can("tenant.member", "tenant"),
+	).Can("staff")
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/admin", nil), "X-Staff", "yes")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	for path, code := range map[string]int{
+		"/tenants/acme/billing":  http.StatusOK,
+		"/tenants/other/billing": http.StatusForbidden,
+	} {
+		rec = record(r, httptest.NewRequest(http.MethodGet, path, nil), "X-Staff", "yes")
+		assert.Equal(t, code, rec.Code, path)
+	}
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/tenants/acme/billing", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestMissingPolicy(t *testing.T) {
+	var reported error
+	r := router.New().OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	r.Get("/", helloHandler).Can("unknown")
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.EqualError(t, reported, `router: no policy for ability "unknown"`)
+}
+
+func TestAudit(t *testing.T) {
+	r := router.New()
+	r.Put("posts/{post}", helloHandler).Can("posts.update", "post").Name("posts.update")
+	r.Group(
+		router.Get("admin", helloHandler),
+		router.Get("tenants/{tenant}/billing", helloHandler).Can("tenant.member", "tenant"),
+	).Can("staff")
+	r.Get("/", helloHandler).Auth("web")
+
+	for _, route := range r.Routes() {
+		if route.Pattern() == "/tenants/{tenant}/billing" {
+			assert.Equal(t, []string{"staff", "tenant.member({tenant})"}, route.Abilities())
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Audit(&buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Regexp(t, `^METHODS\s+PATTERN\s+NAME\s+GUARD\s+ABILITIES$`, lines[0])
+	assert.Contains(t, buf.String(), "posts.update({post})")
+	assert.Regexp(t, `(?m)^GET\s+/\s+-\s+web\s+-$`, buf.String())
+	assert.Regexp(t, `(?m)^GET\s+/tenants/\{tenant\}/billing\s+-\s+-\s+`+
+		`staff, tenant.member\(\{tenant\}\)$`, buf.String())
+}
@@ -0,0 +1,42 @@
+package router
+
+// compare orders two routes by precedence. It returns a negative number when `a`
+// should be matched before `b`, a positive number when `b` should be matched
+// before `a`, and zero when neither is more specific than the other.
+//
+// A higher Priority always wins. Otherwise, the routes' segments are compared
+// from left to right and the route with the more specific segment at the first
+// difference wins. When one route runs out of segments first, it wins if the
+// other continues with an optional or catch-all segment, and loses otherwise,
+// so that a parameter with a pattern spanning several segments doesn't swallow
+// a longer route.
+func compare(a *Route, b *Route) int {
+	if a.priority != b.priority {
+		return b.priority - a.priority
+	}
+
+	for i := 0; i < len(a.segments) && i < len(b.segments); i++ {
+		if d := a.segments[i].rank() - b.segments[i].rank(); d != 0 {
+			return d
+		}
+	}
+
+	switch {
+	case len(a.segments) > len(b.segments):
+		return trailing(a.segments[len(b.segments)])
+	case len(b.segments) > len(a.segments):
+		return -trailing(b.segments[len(a.segments)])
+	}
+
+	return 0
+}
+
+// trailing orders a route that continues with the segment `s` against a route
+// that has already run out of segments.
+func trailing(s segment) int {
+	if s.optional() || s.catchAll() {
+		return 1
+	}
+
+	return -1
+}
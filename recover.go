@@ -0,0 +1,64 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError describes a panic recovered while serving a request. It is passed
+// to the router's error hook.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+	// Route is the pattern of the matched route, if a route was matched.
+	Route  string
+	Params map[string]string
+}
+
+func (e PanicError) Error() string {
+	route := e.Route
+	if route == "" {
+		route = "(none)"
+	}
+
+	return fmt.Sprintf("panic: %v [route %s, params %v]\n%s", e.Value, route, e.Params, e.Stack)
+}
+
+// Unwrap returns the value passed to panic, if it was an error.
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic handles a panic recovered while serving r. The panic is reported
+// to the router's error hook and, if the response hasn't been started, a 500
+// response is written. http.ErrAbortHandler is re-panicked, so that net/http
+// aborts the response as the handler intended.
+func (router *Router) recoverPanic(t *table, w *responseWriter, r *http.Request, v interface{}) {
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	err := PanicError{Value: v, Stack: debug.Stack(), Params: Params(r)}
+	if p, ok := v.(handlerPanic); ok {
+		err.Value, err.Stack = p.value, p.stack
+	}
+	if route := CurrentRoute(r); route != nil {
+		err.Route = route.Pattern()
+	}
+
+	if t.onError != nil {
+		t.onError(r, err)
+	} else {
+		log.Printf("router: %s %s: %v", r.Method, r.URL.Path, err)
+	}
+
+	if !w.written() {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("500 internal server error"))
+	}
+}
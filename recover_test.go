@@ -0,0 +1,89 @@
+package router_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanicsAreRecovered(t *testing.T) {
+	var reported error
+	r := router.New().OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	r.Get("users/{id}", func() string {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "500 internal server error", rec.Body.String())
+
+	var panicErr router.PanicError
+	if assert.True(t, errors.As(reported, &panicErr)) {
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.Equal(t, "/users/{id}", panicErr.Route)
+		assert.Equal(t, map[string]string{"id": "42"}, panicErr.Params)
+		assert.Contains(t, string(panicErr.Stack), "recover_test.go")
+	}
+}
+
+func TestMalformedQueryIsBadRequest(t *testing.T) {
+	var reported error
+	r := router.New().OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	r.Get("/", helloHandler)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?q=%zz", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "400 bad request", rec.Body.String())
+	assert.NoError(t, reported)
+}
+
+func TestPanicAfterWritingKeepsResponse(t *testing.T) {
+	r := router.New().OnError(func(*http.Request, error) {})
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestAbortHandlerIsRepanicked(t *testing.T) {
+	r := router.New()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestResponseWriterKeepsOptionalInterfaces(t *testing.T) {
+	var isFlusher, isHijacker bool
+	r := router.New()
+	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		_, isFlusher = w.(http.Flusher)
+		_, isHijacker = w.(http.Hijacker)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, isFlusher)
+	assert.False(t, isHijacker)
+}
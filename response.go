@@ -0,0 +1,154 @@
+package router
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is an http.ResponseWriter that records the status code and size
+// of the response written through it.
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status returns the status code of the response, or 0 if nothing has been
+	// written yet.
+	Status() int
+	// BytesWritten returns the number of bytes of the response body written.
+	BytesWritten() int64
+}
+
+// WrapResponseWriter wraps w in a ResponseWriter. The returned writer implements
+// the same optional interfaces out of http.Flusher, http.Hijacker and
+// io.ReaderFrom as w does, so wrapping doesn't break streaming or websocket
+// handlers.
+func WrapResponseWriter(w http.ResponseWriter) ResponseWriter {
+	wrapped, _ := wrapResponseWriter(w)
+	return wrapped.(ResponseWriter)
+}
+
+// responseWriter wraps an http.ResponseWriter to record the status code and the
+// number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for use by
+// http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) BytesWritten() int64 {
+	return w.bytes
+}
+
+// written determines whether the response has been started.
+func (w *responseWriter) written() bool {
+	return w.status != 0
+}
+
+type flusher struct{ *responseWriter }
+
+func (w flusher) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ *responseWriter }
+
+func (w hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type readerFrom struct{ *responseWriter }
+
+func (w readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+	w.bytes += n
+	return n, err
+}
+
+// wrapResponseWriter wraps w in a responseWriter, returning both the writer to
+// pass to handlers and the responseWriter itself. See WrapResponseWriter.
+func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWriter) {
+	rw := &responseWriter{ResponseWriter: w}
+
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isReaderFrom := w.(io.ReaderFrom)
+
+	switch {
+	case isFlusher && isHijacker && isReaderFrom:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			readerFrom
+		}{rw, flusher{rw}, hijacker{rw}, readerFrom{rw}}, rw
+	case isFlusher && isHijacker:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+		}{rw, flusher{rw}, hijacker{rw}}, rw
+	case isFlusher && isReaderFrom:
+		return struct {
+			*responseWriter
+			flusher
+			readerFrom
+		}{rw, flusher{rw}, readerFrom{rw}}, rw
+	case isHijacker && isReaderFrom:
+		return struct {
+			*responseWriter
+			hijacker
+			readerFrom
+		}{rw, hijacker{rw}, readerFrom{rw}}, rw
+	case isFlusher:
+		return struct {
+			*responseWriter
+			flusher
+		}{rw, flusher{rw}}, rw
+	case isHijacker:
+		return struct {
+			*responseWriter
+			hijacker
+		}{rw, hijacker{rw}}, rw
+	case isReaderFrom:
+		return struct {
+			*responseWriter
+			readerFrom
+		}{rw, readerFrom{rw}}, rw
+	}
+
+	return rw, rw
+}
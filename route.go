@@ -1,9 +1,11 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Route is a single entrypoint into the router.
@@ -14,11 +16,85 @@ type Route struct {
 	// been transformed into an http.Handler
 	rawHandler interface{}
 	handler    http.Handler
+	// served is the handler wrapped in its full middleware chain. It is only
+	// set on the frozen copies held by a published route table.
+	served http.Handler
 
 	regex *regexp.Regexp
+	// caselessRegex is a case-insensitive copy of regex, used when the router
+	// is case-insensitive. It is compiled along with regex, so that publishing
+	// the route table doesn't compile it again.
+	caselessRegex *regexp.Regexp
+	// pattern is the route's full path, including any group prefix.
+	pattern string
+	// site is the file:line at which the route was defined.
+	site string
+	// segments is the parsed form of the pattern, used to order routes by how
+	// specific they are.
+	segments []segment
+	// priority overrides the specificity-based precedence of the route. Routes
+	// with a higher priority are matched first.
+	priority int
 
 	// The {} bits of a route
-	params []string
+	params []*param
+
+	// name identifies the route when generating URLs.
+	name string
+
+	// mount is the handler mounted beneath the route's path, if any.
+	mount http.Handler
+
+	// version is the API version the route belongs to. It is copied from the
+	// route's group when the route is published.
+	version string
+
+	// versionPrefix is the path segment that selects the route's version, such
+	// as `/v2`, when the router reads versions from the path. It comes after
+	// the first versionAt segments of the pattern, which are those of any mount
+	// points the route is seen through.
+	versionPrefix string
+	versionAt     int
+
+	// produces is the content type of the route's responses when its handler
+	// returns a string.
+	produces string
+
+	// encoder and onError are the router's Encoder and error hook, copied when
+	// the route is published.
+	encoder Encoder
+	onError func(*http.Request, error)
+
+	// timeout limits how long the route's handler may take to respond, and
+	// maxBodySize limits the size of request bodies. When zero, the values
+	// from the route's group or router are used.
+	timeout     time.Duration
+	maxBodySize int64
+
+	// etag enables conditional requests for the route, and currentETag
+	// returns the ETag of the resource a request refers to.
+	etag        bool
+	currentETag ETagFunc
+
+	// signingKeys are the router's keys for verifying signed URLs, copied when
+	// the route is published.
+	signingKeys [][]byte
+
+	// auth is the name of the guard that authenticates requests to the route.
+	// Frozen routes hold the guard from the route's group if the route
+	// doesn't name one.
+	auth string
+
+	// abilities are checked before the route's handler is called. Frozen
+	// routes hold the abilities required by the route's group too.
+	abilities []ability
+
+	// security adjusts the security headers sent with the route's responses.
+	security func(*SecurityConfig)
+
+	// csrfExempt stops the CSRF middleware from checking requests to the
+	// route.
+	csrfExempt bool
 
 	group *Group
 
@@ -30,10 +106,118 @@ type Route struct {
 // Middleware defines additional logic on a single route definition by wrapping the
 // route's handler in extra layers of logic.
 func (route *Route) Middleware(middleware ...Middleware) *Route {
-	route.middleware = append(route.middleware, middleware...)
+	route.modify(func() {
+		route.middleware = append(route.middleware, middleware...)
+	})
 	return route
 }
 
+// Name sets the name of the route, which can be used to generate URLs for it
+// with Router.URL.
+func (route *Route) Name(name string) *Route {
+	route.modify(func() {
+		route.name = name
+	})
+	return route
+}
+
+// GetName returns the name of the route, or an empty string if it has none.
+func (route *Route) GetName() string {
+	return route.name
+}
+
+// Produces sets the content type of the route's responses when its handler
+// returns a string, overriding the router's default.
+func (route *Route) Produces(contentType string) *Route {
+	route.modify(func() {
+		route.produces = contentType
+	})
+	return route
+}
+
+// Priority overrides the precedence of the route. By default, routes with static
+// segments take precedence over those with constrained parameters, which take
+// precedence over unconstrained parameters. Routes with a higher priority are
+// matched before any route with a lower priority, regardless of specificity.
+func (route *Route) Priority(n int) *Route {
+	route.modify(func() {
+		route.priority = n
+	})
+	return route
+}
+
+// Timeout limits how long the route's handler, including its middleware, may take
+// to respond. When the timeout passes, the request's context is cancelled and a
+// 503 response is sent, or the response set with Router.OnTimeout. Anything the
+// handler writes after that is discarded. The route's timeout overrides any set
+// on its group.
+func (route *Route) Timeout(d time.Duration) *Route {
+	route.modify(func() {
+		route.timeout = d
+	})
+	return route
+}
+
+// MaxBodySize limits request bodies for the route to n bytes, overriding any
+// limit set on its group or router. Requests with larger bodies are rejected
+// with a 413 response.
+func (route *Route) MaxBodySize(n int64) *Route {
+	route.modify(func() {
+		route.maxBodySize = n
+	})
+	return route
+}
+
+// ETag enables conditional requests for the route. Responses to GET and HEAD
+// requests are given an ETag, and clients that already have the response receive
+// a 304. The ETag is the one the handler sets, if any, then the one returned by
+// current, and otherwise a weak ETag hashed from the response body.
+//
+// current may be nil. If it is set, GET requests that match the current ETag are
+// answered without calling the handler, and requests with an If-Match header
+// that doesn't match it are rejected with a 412.
+func (route *Route) ETag(current ETagFunc) *Route {
+	route.modify(func() {
+		route.etag = true
+		route.currentETag = current
+	})
+	return route
+}
+
+// Auth requires requests to the route to be authenticated by the guard registered
+// with Router.Guard under the given name, overriding any guard set on its group.
+// The guard runs after the router and group middleware, and before the route's
+// own middleware.
+func (route *Route) Auth(guard string) *Route {
+	route.modify(func() {
+		route.auth = guard
+	})
+	return route
+}
+
+// CSRFExempt stops the CSRF middleware from checking requests to the route, for
+// routes that are called by other servers rather than browsers, such as webhooks.
+// Routes on mounted routers can be exempt from the middleware of the router they
+// are mounted on.
+func (route *Route) CSRFExempt() *Route {
+	route.modify(func() {
+		route.csrfExempt = true
+	})
+	return route
+}
+
+// modify applies fn to the route. If the route has already been registered on a
+// router, the change is made under the router's lock and a new route table is
+// published.
+func (route *Route) modify(fn func()) {
+	if route.router == nil {
+		fn()
+		return
+	}
+
+	route.router.update(fn)
+}
+
 // NewRoute creates a new route definition for a given method, path and handler.
 func NewRoute(methods []string, path string, handler interface{}) *Route {
 	return newHandlerRoute(methods, path, handler)
@@ -48,6 +232,7 @@ func newHandlerRoute(methods []string, path string, handler interface{}) *Route
 		methods:    methods,
 		path:       path,
 		rawHandler: handler,
+		site:       callerSite(),
 	}
 
 	r.regex = r.calculateRouteRegex()
@@ -55,9 +240,33 @@ func newHandlerRoute(methods []string, path string, handler interface{}) *Route
 }
 
 func (route *Route) Serve(w http.ResponseWriter, r *http.Request) {
+	handler := route.served
+	if handler == nil {
+		handler = route.compose()
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// compose wraps the route's handler in the route, group and router middleware.
+// The route's timeout applies to the handler and route middleware only, so that
+// group and router middleware see the response sent when it passes. Conditional
+// requests are served inside the guard and authorizer, so they never bypass
+// authentication.
+func (route *Route) compose() http.Handler {
 	handler := route.handler
+	for _, m := range route.middleware {
+		handler = m(handler)
+	}
+	handler = route.withConditional(route.withTimeout(handler))
+
 	var mw []Middleware
-	mw = append(mw, route.middleware...)
+	if authorize := route.authorizer(); authorize != nil {
+		mw = append(mw, authorize)
+	}
+	if guard := route.guard(); guard != nil {
+		mw = append(mw, guard)
+	}
 	if route.group != nil {
 		mw = append(mw, route.group.middleware...)
 	}
@@ -69,7 +278,104 @@ func (route *Route) Serve(w http.ResponseWriter, r *http.Request) {
 		handler = m(handler)
 	}
 
-	handler.ServeHTTP(w, r)
+	return handler
+}
+
+// guard returns the middleware of the guard that authenticates requests to the
+// route, or nil if the route doesn't require authentication. If no guard is
+// registered under the route's guard name, requests are refused with a 500.
+func (route *Route) guard() Middleware {
+	name := route.auth
+	if name == "" && route.group != nil {
+		name = route.group.auth
+	}
+	if name == "" {
+		return nil
+	}
+
+	if route.router != nil {
+		if guard, ok := route.router.guards[name]; ok {
+			return guard
+		}
+	}
+
+	return func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ServerError(w, r, fmt.Errorf("router: no guard named %q", name))
+		})
+	}
+}
+
+// freeze returns a copy of the route with its middleware chain composed, for use
+// in a published route table. The copy is never modified after it is created.
+func (route *Route) freeze() *Route {
+	frozen := *route
+	frozen.methods = append([]string(nil), route.methods...)
+	frozen.params = append([]*param(nil), route.params...)
+	frozen.middleware = append([]Middleware(nil), route.middleware...)
+	frozen.served = route.compose()
+	frozen.abilities = route.requiredAbilities()
+	if route.group != nil {
+		frozen.version = route.group.version
+		if frozen.timeout == 0 {
+			frozen.timeout = route.group.timeout
+		}
+		if frozen.maxBodySize == 0 {
+			frozen.maxBodySize = route.group.maxBodySize
+		}
+		if frozen.auth == "" {
+			frozen.auth = route.group.auth
+		}
+	}
+	if route.router != nil {
+		if frozen.produces == "" {
+			frozen.produces = route.router.produces
+		}
+		if frozen.maxBodySize == 0 {
+			frozen.maxBodySize = route.router.maxBodySize
+		}
+		frozen.encoder = route.router.encoder
+		frozen.onError = route.router.onError
+		frozen.signingKeys = route.router.signingKeys
+		if _, ok := route.router.versioning.(pathVersion); ok && frozen.version != "" {
+			frozen.versionPrefix = "/" + frozen.version
+		}
+	}
+
+	return &frozen
+}
+
+// withConditional serves conditional requests for handler if the route, or its
+// group, enables them with ETag.
+func (route *Route) withConditional(handler http.Handler) http.Handler {
+	enabled, current := route.etag, route.currentETag
+	if !enabled && route.group != nil {
+		enabled, current = route.group.etag, route.group.currentETag
+	}
+	if !enabled {
+		return handler
+	}
+
+	return conditional(handler, current)
+}
+
+// withTimeout wraps handler in the route's timeout, or its group's if the route
+// doesn't set one.
+func (route *Route) withTimeout(handler http.Handler) http.Handler {
+	d := route.timeout
+	if d == 0 && route.group != nil {
+		d = route.group.timeout
+	}
+	if d <= 0 {
+		return handler
+	}
+
+	var onTimeout http.Handler
+	if route.router != nil {
+		onTimeout = route.router.onTimeout
+	}
+
+	return timeoutHandler(handler, d, onTimeout)
 }
 
 func (r *Route) buildHandler() {
@@ -134,8 +440,8 @@ func Redirect(from string, to string) *Route {
 }
 
 // matches determines if the route matches the incoming request.
-func (r *Route) matches(router *Router, req *http.Request) bool {
-	for _, v := range router.validators {
+func (r *Route) matches(t *table, req *http.Request) bool {
+	for _, v := range t.validators {
 		if !v.Matches(r, req) {
 			return false
 		}
@@ -152,6 +458,16 @@ func (r *Route) Regex() *regexp.Regexp {
 	return r.regex
 }
 
+// Pattern returns the route's full path definition, including any group prefix.
+func (r *Route) Pattern() string {
+	return r.pattern
+}
+
+// Site returns the file and line number at which the route was defined.
+func (r *Route) Site() string {
+	return r.site
+}
+
 func (r *Route) calculateRouteRegex() *regexp.Regexp {
 	fullURI := r.path
 	if r.group != nil {
@@ -161,23 +477,17 @@ func (r *Route) calculateRouteRegex() *regexp.Regexp {
 		}
 	}
 
-	if !strings.ContainsAny(fullURI, "{}") {
-		return regexp.MustCompile("^" + fullURI + "$")
-	}
-
-	r.path = r.normalizeParamaterizedPath(r.path)
-
-	rx := regexp.MustCompile("{([^}:]+):?([^}]+)?}")
-	res2 := rx.FindAllStringSubmatch(r.path, -1)
-	for _, v := range res2 {
-		r.params = append(r.params, v[1])
+	segs, err := parsePattern(fullURI)
+	if err != nil {
+		panic(err)
 	}
 
-	return regexp.MustCompile("^" + rx.ReplaceAllString(r.path, "(?P<$1>$2)") + "$")
-}
+	r.pattern = fullURI
+	r.segments = segs
+	r.params = params(segs)
 
-func (r *Route) normalizeParamaterizedPath(path string) string {
-	regex := regexp.MustCompile("{([^:}]+)}")
+	rx := compilePattern(segs)
+	r.caselessRegex = caseless(rx)
 
-	return regex.ReplaceAllString(path, "{$1:.+}")
+	return rx
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRouteInference(t *testing.T) {
@@ -53,3 +54,105 @@ var (
 		w.Write([]byte("func"))
 	}
 )
+
+func TestRouteParameterSyntax(t *testing.T) {
+	rtr := router.New()
+	rtr.Get("users/{id}", func(r *http.Request) string {
+		return "user " + router.Param(r, "id")
+	})
+	rtr.Get("posts/{page?=1}", func(r *http.Request) string {
+		return "page " + router.Param(r, "page")
+	})
+	rtr.Get("archive/{year:[0-9]{4}}/{month?}", func(r *http.Request) string {
+		return "archive " + router.Param(r, "year") + " " + router.Param(r, "month")
+	})
+	rtr.Get("files/{path...}", func(r *http.Request) string {
+		return "file " + router.Param(r, "path")
+	})
+
+	server := httptest.NewServer(rtr)
+	defer server.Close()
+
+	cases := map[string]string{
+		"/users/42":           "user 42",
+		"/users/42/posts":     "404 not found",
+		"/posts":              "page 1",
+		"/posts/3":            "page 3",
+		"/archive/2020":       "archive 2020 ",
+		"/archive/2020/06":    "archive 2020 06",
+		"/archive/20":         "404 not found",
+		"/files/":             "file ",
+		"/files/docs/a/b.txt": "file docs/a/b.txt",
+	}
+
+	for path, expected := range cases {
+		t.Run(path, func(t *testing.T) {
+			assert.Equal(t, expected, get(server.URL+path))
+		})
+	}
+}
+
+func TestInvalidRouteParameterSyntaxPanics(t *testing.T) {
+	patterns := []string{
+		"files/{path...}/edit",
+		"posts/{page?}/comments",
+		"posts/page-{page?}",
+		"users/{id",
+		"users/{}",
+	}
+
+	for _, p := range patterns {
+		t.Run(p, func(t *testing.T) {
+			assert.Panics(t, func() { router.Get(p, helloHandler) })
+		})
+	}
+}
+
+func TestRouteURLGeneration(t *testing.T) {
+	rtr := router.New()
+	rtr.Get("users/{id:[0-9]+}", helloHandler).Name("users.show")
+	rtr.Get("posts/{page?=1}/{sort?}", helloHandler).Name("posts.index")
+	rtr.Get("files/{path...}", helloHandler).Name("files.show")
+	rtr.Group(
+		router.Get("dashboard", helloHandler).Name("admin.dashboard"),
+	).Prefix("admin")
+
+	type testcase struct {
+		name     string
+		params   map[string]string
+		expected string
+		err      bool
+	}
+
+	cases := []testcase{
+		{name: "users.show", params: map[string]string{"id": "42"}, expected: "/users/42"},
+		{
+			name:     "users.show",
+			params:   map[string]string{"id": "42", "tab": "posts"},
+			expected: "/users/42?tab=posts",
+		},
+		{name: "users.show", params: map[string]string{"id": "bob"}, err: true},
+		{name: "users.show", err: true},
+		{name: "posts.index", expected: "/posts"},
+		{name: "posts.index", params: map[string]string{"page": "2"}, expected: "/posts/2"},
+		{name: "posts.index", params: map[string]string{"sort": "new"}, expected: "/posts/1/new"},
+		{
+			name:     "files.show",
+			params:   map[string]string{"path": "a b/c.txt"},
+			expected: "/files/a%20b/c.txt",
+		},
+		{name: "admin.dashboard", expected: "/admin/dashboard"},
+		{name: "missing", err: true},
+	}
+
+	for _, tc := range cases {
+		url, err := rtr.URL(tc.name, tc.params)
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, url, tc.name)
+	}
+}
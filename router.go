@@ -2,10 +2,18 @@ package router
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 )
 
 type Router struct {
+	// mu serialises changes to the route definitions. Requests never take it;
+	// they read the most recently published table instead.
+	mu    sync.Mutex
+	table atomic.Value
+
 	groups       []*Group
 	defaultGroup *Group
 
@@ -16,13 +24,55 @@ type Router struct {
 	middleware []Middleware
 
 	transformers map[string]interface{}
+
+	// slashes, clean and caseInsensitive control how request paths are
+	// matched to routes.
+	slashes         SlashPolicy
+	clean           bool
+	caseInsensitive bool
+
+	// versioning selects between versions of routes defined with Version.
+	versioning     VersionStrategy
+	defaultVersion string
+
+	// produces is the default content type of responses from string-returning
+	// handlers.
+	produces string
+
+	// encoder writes the values returned by value-returning handlers.
+	encoder Encoder
+
+	// onError is called with errors that occur while serving a request which
+	// can't be returned to the client.
+	onError func(*http.Request, error)
+
+	// maxBodySize is the default limit on the size of request bodies, and
+	// onTimeout writes the response to requests that exceed a route's timeout.
+	maxBodySize int64
+	onTimeout   http.Handler
+
+	// signingKeys sign and verify signed URLs. The first key signs new URLs,
+	// and any of them can verify a URL.
+	signingKeys [][]byte
+
+	// guards are middleware that authenticate requests, keyed by the names
+	// routes refer to them by.
+	guards map[string]Middleware
+
+	// policies decide whether requests may perform abilities, and models load
+	// the models that abilities are checked against, keyed by parameter name.
+	policies map[string]Policy
+	models   map[string]ModelFunc
+
+	// maintenance is set while the router is down for maintenance.
+	maintenance *maintenance
 }
 
 // New creates a new Router instance.
 func New() *Router {
 	rtr := &Router{
 		validators: []Validator{
-			URI{}, Method{},
+			URI{}, Method{}, Version{},
 		},
 		transformers: map[string]interface{}{},
 	}
@@ -37,48 +87,148 @@ func New() *Router {
 		}
 	}
 
+	rtr.publish()
+
 	return rtr
 }
 
 func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	route, err := router.findRoute(r)
-	if err != nil {
-		if err.Error() == "route not found" {
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("404 not found"))
-			return
+	t := router.routes()
+
+	w, rw := wrapResponseWriter(w)
+	defer func() {
+		if v := recover(); v != nil {
+			router.recoverPanic(t, rw, r, v)
 		}
+	}()
+
+	route, r, ok := router.match(t, w, r)
+	if !ok {
+		return
+	}
 
-		if err.Error() == "method not allowed" {
+	r = withServed(withRoute(r, route), route)
+	if !parseForm(w, r, route) {
+		return
+	}
+
+	if r = runServedHooks(w, r, route); r == nil {
+		return
+	}
+
+	r = route.extractParams(r)
+	route.Serve(w, r)
+}
+
+// match finds the route for the request. If the request has already been
+// responded to, because it was redirected, refused during maintenance or
+// didn't match a route, ok is false.
+func (router *Router) match(
+	t *table, w http.ResponseWriter, r *http.Request,
+) (*Route, *http.Request, bool) {
+	if t.maintenance.bypass(w, r) {
+		return nil, r, false
+	}
+
+	route, r, err := router.resolve(t, w, r)
+	if route == nil && err == nil {
+		return nil, r, false
+	}
+
+	if t.maintenance.refuses(w, r, route, err) {
+		return nil, r, false
+	}
+
+	if err != nil {
+		switch err.Error() {
+		case "method not allowed":
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			w.Write([]byte("405 method not allowed"))
-			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 not found"))
 		}
+		return nil, r, false
 	}
 
-	if err := r.ParseForm(); err != nil {
-		panic(err)
+	return route, r, true
+}
+
+// parseForm limits the size of the request body to the route's limit and
+// parses the request's form. If the body is too large or the form is malformed,
+// the error response is written and false is returned.
+func parseForm(w http.ResponseWriter, r *http.Request, route *Route) bool {
+	if route.maxBodySize > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, route.maxBodySize)
 	}
 
-	match := route.Regex().FindStringSubmatch(r.RequestURI)
-	for _, k := range route.params {
-		i := route.Regex().SubexpIndex(k)
-		r.Form.Add(k, match[i])
+	err := r.ParseForm()
+	if err == nil {
+		return true
 	}
 
-	route.Serve(w, r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		w.Write([]byte("413 request entity too large"))
+		return false
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write([]byte("400 bad request"))
+	return false
 }
 
-func (router *Router) findRoute(r *http.Request) (*Route, error) {
-	for _, group := range router.groups {
-		for _, route := range group.routes {
-			if route.matches(router, r) {
-				return route, nil
+// extractParams returns a shallow copy of the request with the route's
+// parameters from its path attached, and adds them to the request's form.
+// Parameters from any router this one is mounted on are kept, so that handlers
+// see the parameters from every level.
+func (route *Route) extractParams(r *http.Request) *http.Request {
+	params := map[string]string{}
+	for k, v := range Params(r) {
+		params[k] = v
+	}
+
+	match := route.Regex().FindStringSubmatch(r.URL.Path)
+	for _, p := range route.params {
+		v := match[route.Regex().SubexpIndex(p.name)]
+		if route.mount != nil && p.name == mountParam {
+			r = withMountPath(r, v)
+			continue
+		}
+
+		if v == "" && p.optional {
+			if p.def == "" {
+				continue
 			}
+			v = p.def
 		}
+
+		params[p.name] = v
+		r.Form.Add(p.name, v)
 	}
 
-	return &Route{}, errors.New("route not found")
+	return withParams(r, params)
+}
+
+// findRoute finds the route for the request. The returned request is the one
+// the route should be served, which may differ from `r` for versioned routes.
+func (router *Router) findRoute(t *table, r *http.Request) (*Route, *http.Request, error) {
+	if t.versioned {
+		if route, req, ok := router.findVersionedRoute(t, r); ok {
+			return route, req, nil
+		}
+
+		return &Route{}, r, errors.New("route not found")
+	}
+
+	for _, route := range t.routes {
+		if route.matches(t, r) {
+			return route, r, nil
+		}
+	}
+
+	return &Route{}, r, errors.New("route not found")
 }
 
 // Get defines a new `GET` route on the router, at the given path.
@@ -143,13 +293,68 @@ func (router *Router) Redirect(from string, to string) *Route {
 func (router *Router) addRoute(methods []string, path string, handler interface{}) *Route {
 	r := NewRoute(methods, path, handler)
 
-	router.defaultGroup.Add(r)
-
-	r.router = router
+	router.update(func() {
+		router.defaultGroup.add(r)
+	})
 
 	return r
 }
 
+// URL generates a URL for the route with the given name. Routes on mounted
+// routers are included, with the mount prefix added to their paths. See
+// Route.URL.
+func (router *Router) URL(name string, params map[string]string) (string, error) {
+	if route := router.named(name); route != nil {
+		return route.URL(params)
+	}
+
+	return "", fmt.Errorf("router: no route named %q", name)
+}
+
+// named returns the route with the given name, or nil if there isn't one.
+func (router *Router) named(name string) *Route {
+	for _, route := range router.Routes() {
+		if route.name == name {
+			return route
+		}
+	}
+
+	return nil
+}
+
+// URLFor generates a URL for the route with the given name on the router that
+// is serving the request. If that router is mounted on another, the URL
+// includes the mount prefix, and routes on the routers it is mounted on are
+// looked up too. See Route.URL.
+func URLFor(r *http.Request, name string, params map[string]string) (string, error) {
+	route := CurrentRoute(r)
+	if route == nil || route.router == nil {
+		return "", fmt.Errorf("router: cannot generate URL for %q outside of a route", name)
+	}
+
+	router, m := route.router, mountOf(r)
+	for {
+		prefix := ""
+		if m != nil {
+			prefix = m.prefix
+		}
+
+		if named := router.named(name); named != nil {
+			u, err := named.URL(params)
+			if err != nil {
+				return "", err
+			}
+
+			return prefix + u, nil
+		}
+
+		if m == nil {
+			return "", fmt.Errorf("router: no route named %q", name)
+		}
+		router, m = m.parent, m.up
+	}
+}
+
 func methodsMatch(routeA *Route, routeB *Route) bool {
 	if len(routeA.methods) != len(routeB.methods) {
 		return false
@@ -166,13 +371,11 @@ func methodsMatch(routeA *Route, routeB *Route) bool {
 
 // Group creates a new route Group for the Router instance.
 func (router *Router) Group(routes ...*Route) *Group {
-	g := newGroup(router, routes...)
-
-	for _, route := range routes {
-		route.router = router
-	}
-
-	router.groups = append(router.groups, g)
+	var g *Group
+	router.update(func() {
+		g = newGroup(router, routes...)
+		router.groups = append(router.groups, g)
+	})
 
 	return g
 }
@@ -181,12 +384,58 @@ func (router *Router) Group(routes ...*Route) *Group {
 // does not have a corresponding route definition, the Fallback handler is
 // called for the request.
 func (router *Router) Fallback(handler http.Handler) *Router {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
 	router.fallback = handler
 	return router
 }
 
+// Produces sets the default content type of responses written by handlers that
+// return a string. Routes can override it with Route.Produces. If no content
+// type is set, responses are served as HTML.
+func (router *Router) Produces(contentType string) *Router {
+	router.update(func() {
+		router.produces = contentType
+	})
+	return router
+}
+
+// MaxBodySize limits request bodies to n bytes for every route that doesn't set
+// its own limit. See Route.MaxBodySize.
+func (router *Router) MaxBodySize(n int64) *Router {
+	router.update(func() {
+		router.maxBodySize = n
+	})
+	return router
+}
+
+// OnTimeout sets the handler that writes the response to requests exceeding a
+// route's timeout. By default, a 503 response is sent.
+func (router *Router) OnTimeout(handler http.Handler) *Router {
+	router.update(func() {
+		router.onTimeout = handler
+	})
+	return router
+}
+
+// Guard registers middleware that authenticates requests under the given name.
+// Routes and groups require authentication by a guard with Route.Auth and
+// Group.Auth.
+func (router *Router) Guard(name string, guard Middleware) *Router {
+	router.update(func() {
+		if router.guards == nil {
+			router.guards = map[string]Middleware{}
+		}
+		router.guards[name] = guard
+	})
+	return router
+}
+
 // Middleware appends the given middleware `fns` to the Router instance.
 func (router *Router) Middleware(fns ...Middleware) *Router {
-	router.middleware = append(router.middleware, fns...)
+	router.update(func() {
+		router.middleware = append(router.middleware, fns...)
+	})
 	return router
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/gostalt/router"
@@ -131,6 +132,62 @@ func TestRouteDispatching(t *testing.T) {
 	})
 }
 
+func TestConcurrentRouteRegistration(t *testing.T) {
+	rtr := router.New()
+	rtr.Get("/", helloHandler)
+
+	server := httptest.NewServer(rtr)
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/plugin/%d", i)
+			rtr.Get(path, helloHandler).Middleware(oneMiddleware)
+			rtr.Group(router.Get(path, helloHandler)).Prefix("group").Middleware(twoMiddleware)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "Hello", get(server.URL))
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "1Hello", get(fmt.Sprintf("%s/plugin/%d", server.URL, i)))
+		assert.Equal(t, "2Hello", get(fmt.Sprintf("%s/group/plugin/%d", server.URL, i)))
+	}
+}
+
+func TestRoutePrecedence(t *testing.T) {
+	r := router.New()
+	r.Get("/users/{id}", func() string { return "param" })
+	r.Get("/users/{id:[0-9]+}", func() string { return "constrained" })
+	r.Group(router.Get("/users/me", func() string { return "static" }))
+	r.Get("/posts/{id}", func() string { return "priority" }).Priority(1)
+	r.Get("/posts/latest", func() string { return "static" })
+	r.Get("/docs/{path...}", func() string { return "catch-all" })
+	r.Get("/docs/{page}", func() string { return "param" })
+	r.Get("/feed/{page?}", func() string { return "optional" })
+	r.Get("/feed", func() string { return "static" })
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	assert.Equal(t, "static", get(server.URL+"/users/me"))
+	assert.Equal(t, "constrained", get(server.URL+"/users/42"))
+	assert.Equal(t, "param", get(server.URL+"/users/bob"))
+	assert.Equal(t, "priority", get(server.URL+"/posts/latest"))
+	assert.Equal(t, "param", get(server.URL+"/docs/intro"))
+	assert.Equal(t, "catch-all", get(server.URL+"/docs/intro/setup"))
+	assert.Equal(t, "static", get(server.URL+"/feed"))
+	assert.Equal(t, "optional", get(server.URL+"/feed/2"))
+}
+
 // get is a convenience method that fires off a GET request and assumes a positive
 // response with no errors. If errors occur, a panic is thrown.
 func get(uri string) string {
@@ -147,6 +204,19 @@ func get(uri string) string {
 	return string(body)
 }
 
+// record serves a request with the handler without starting a server, and
+// returns the recorded response. Headers are set on the request from pairs of
+// names and values.
+func record(h http.Handler, req *http.Request, headers ...string) *httptest.ResponseRecorder {
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
 // post is a convenience method that fires off a POST request and assumes a positive
 // response with no errors. If errors occur, a panic is thrown.
 func post(uri string) string {
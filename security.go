@@ -0,0 +1,192 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// OmitHeader can be set as the value of a SecurityConfig field to leave its
+// header out of responses.
+const OmitHeader = "-"
+
+// noncePlaceholder is replaced in a Content-Security-Policy with the nonce
+// generated for the request.
+const noncePlaceholder = "{nonce}"
+
+// SecurityConfig sets the security headers sent by the SecureHeaders middleware.
+// Empty fields use the defaults below, and fields set to OmitHeader leave their
+// header out.
+type SecurityConfig struct {
+	// HSTS is the Strict-Transport-Security header. It defaults to
+	// "max-age=63072000; includeSubDomains".
+	HSTS string
+	// ContentTypeOptions is the X-Content-Type-Options header. It defaults to
+	// "nosniff".
+	ContentTypeOptions string
+	// FrameOptions is the X-Frame-Options header. It defaults to "DENY".
+	FrameOptions string
+	// ReferrerPolicy is the Referrer-Policy header. It defaults to
+	// "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+	// PermissionsPolicy is the Permissions-Policy header. It defaults to
+	// "camera=(), microphone=(), geolocation=()".
+	PermissionsPolicy string
+
+	// CSP is the Content-Security-Policy header. Any {nonce} in it is replaced
+	// with a nonce generated for each request, which templates can read with
+	// CSPNonce. It defaults to:
+	//
+	//	default-src 'self'; script-src 'self' 'nonce-{nonce}';
+	//	style-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self';
+	//	frame-ancestors 'none'
+	CSP string
+	// ReportOnly sends the policy as Content-Security-Policy-Report-Only, so
+	// that violations are reported but not blocked while a policy is rolled
+	// out.
+	ReportOnly bool
+}
+
+var securityDefaults = SecurityConfig{
+	HSTS:               "max-age=63072000; includeSubDomains",
+	ContentTypeOptions: "nosniff",
+	FrameOptions:       "DENY",
+	ReferrerPolicy:     "strict-origin-when-cross-origin",
+	PermissionsPolicy:  "camera=(), microphone=(), geolocation=()",
+	CSP: "default-src 'self'; script-src 'self' 'nonce-{nonce}'; " +
+		"style-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self'; " +
+		"frame-ancestors 'none'",
+}
+
+// SecureHeaders returns middleware that sets security headers on every response.
+// Routes that need different headers, such as pages that embed third-party
+// widgets, can adjust them with Route.Security.
+func SecureHeaders(c SecurityConfig) Middleware {
+	c = c.withDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := CurrentRoute(r)
+			r = c.apply(w, r, route)
+
+			// A route on a mounted router can adjust the headers once the
+			// mounted router has matched it. Until then, they are set for the
+			// mount point, so that responses that don't reach a route have them.
+			adjust := func(w http.ResponseWriter, r *http.Request, served *Route) *http.Request {
+				if served == route || served.security == nil {
+					return r
+				}
+				return c.apply(w, r, served)
+			}
+			whenServed(w, r, next, adjust)
+		})
+	}
+}
+
+// apply sets the security headers for responses from the route, replacing any
+// set before, and returns the request with the CSP nonce attached.
+func (c SecurityConfig) apply(w http.ResponseWriter, r *http.Request, route *Route) *http.Request {
+	if route != nil && route.security != nil {
+		route.security(&c)
+		c = c.withDefaults()
+	}
+
+	h := w.Header()
+	setHeader(h, "Strict-Transport-Security", c.HSTS)
+	setHeader(h, "X-Content-Type-Options", c.ContentTypeOptions)
+	setHeader(h, "X-Frame-Options", c.FrameOptions)
+	setHeader(h, "Referrer-Policy", c.ReferrerPolicy)
+	setHeader(h, "Permissions-Policy", c.PermissionsPolicy)
+
+	h.Del("Content-Security-Policy")
+	h.Del("Content-Security-Policy-Report-Only")
+
+	var nonce string
+	if c.CSP != OmitHeader {
+		csp := c.CSP
+		if strings.Contains(csp, noncePlaceholder) {
+			nonce = newNonce()
+			csp = strings.ReplaceAll(csp, noncePlaceholder, nonce)
+		}
+
+		name := "Content-Security-Policy"
+		if c.ReportOnly {
+			name += "-Report-Only"
+		}
+		h.Set(name, csp)
+	}
+
+	if nonce != CSPNonce(r) {
+		r = r.WithContext(context.WithValue(r.Context(), nonceKey, nonce))
+	}
+
+	return r
+}
+
+// Security adjusts the security headers sent with the route's responses by the
+// SecureHeaders middleware. fn is passed a copy of the middleware's config to
+// change:
+//
+//	route.Security(func(c *router.SecurityConfig) {
+//		c.CSP += "; frame-src https://widgets.example.com"
+//	})
+func (route *Route) Security(fn func(c *SecurityConfig)) *Route {
+	route.modify(func() {
+		route.security = fn
+	})
+	return route
+}
+
+// CSPNonce returns the nonce generated for the request's Content-Security-Policy,
+// for use in the nonce attribute of inline scripts and styles. It returns an
+// empty string if the policy doesn't use a nonce.
+func CSPNonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(nonceKey).(string)
+	return nonce
+}
+
+// withDefaults returns a copy of the config with empty fields set to their
+// defaults.
+func (c SecurityConfig) withDefaults() SecurityConfig {
+	d := securityDefaults
+	if c.HSTS == "" {
+		c.HSTS = d.HSTS
+	}
+	if c.ContentTypeOptions == "" {
+		c.ContentTypeOptions = d.ContentTypeOptions
+	}
+	if c.FrameOptions == "" {
+		c.FrameOptions = d.FrameOptions
+	}
+	if c.ReferrerPolicy == "" {
+		c.ReferrerPolicy = d.ReferrerPolicy
+	}
+	if c.PermissionsPolicy == "" {
+		c.PermissionsPolicy = d.PermissionsPolicy
+	}
+	if c.CSP == "" {
+		c.CSP = d.CSP
+	}
+
+	return c
+}
+
+func setHeader(h http.Header, name string, value string) {
+	if value == OmitHeader {
+		h.Del(name)
+		return
+	}
+
+	h.Set(name, value)
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
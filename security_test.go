@@ -0,0 +1,111 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureHeaderDefaults(t *testing.T) {
+	var nonce string
+	r := router.New().Middleware(router.SecureHeaders(router.SecurityConfig{}))
+	r.Get("/", func(req *http.Request) string {
+		nonce = router.CSPNonce(req)
+		return `<script nonce="` + nonce + `"></script>`
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	h := rec.Header()
+
+	assert.Equal(t, "max-age=63072000; includeSubDomains", h.Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", h.Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", h.Get("Referrer-Policy"))
+	assert.Equal(t, "camera=(), microphone=(), geolocation=()", h.Get("Permissions-Policy"))
+
+	assert.NotEmpty(t, nonce)
+	assert.Contains(t, h.Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"'")
+	assert.Contains(t, h.Get("Content-Security-Policy"), "frame-ancestors 'none'")
+	assert.NotContains(t, h.Get("Content-Security-Policy"), "{nonce}")
+
+	first := nonce
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotEqual(t, first, nonce)
+}
+
+func TestSecureHeaderConfig(t *testing.T) {
+	r := router.New().Middleware(router.SecureHeaders(router.SecurityConfig{
+		HSTS:       router.OmitHeader,
+		CSP:        "default-src 'self'; report-uri /csp",
+		ReportOnly: true,
+	}))
+	r.Get("/", func(req *http.Request) string {
+		return router.CSPNonce(req)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	h := rec.Header()
+
+	assert.Empty(t, h.Get("Strict-Transport-Security"))
+	assert.Empty(t, h.Get("Content-Security-Policy"))
+	csp := h.Get("Content-Security-Policy-Report-Only")
+	assert.Equal(t, "default-src 'self'; report-uri /csp", csp)
+	assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestRouteSecurityOverrides(t *testing.T) {
+	r := router.New().Middleware(router.SecureHeaders(router.SecurityConfig{}))
+	r.Get("/", helloHandler)
+	r.Get("embed", helloHandler).Security(func(c *router.SecurityConfig) {
+		c.FrameOptions = router.OmitHeader
+		c.CSP = "default-src 'self'; frame-src https://widgets.example.com; " +
+			"frame-ancestors https://partner.example.com"
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/embed", nil))
+	assert.Empty(t, rec.Header().Get("X-Frame-Options"))
+	csp := rec.Header().Get("Content-Security-Policy")
+	assert.Contains(t, csp, "frame-src https://widgets.example.com")
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+}
+
+func TestMountedRouteSecurityOverrides(t *testing.T) {
+	var nonce string
+	widgets := router.New()
+	widgets.Get("embed", func(req *http.Request) string {
+		nonce = router.CSPNonce(req)
+		return "embed"
+	}).Security(func(c *router.SecurityConfig) {
+		c.FrameOptions = router.OmitHeader
+		c.CSP = "default-src 'self'; frame-ancestors https://partner.example.com"
+	})
+	widgets.Get("/", helloHandler)
+
+	r := router.New().Middleware(router.SecureHeaders(router.SecurityConfig{}))
+	r.Mount("/widgets", widgets)
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, "/widgets/embed", nil))
+	assert.Empty(t, rec.Header().Get("X-Frame-Options"))
+	csp := rec.Header().Get("Content-Security-Policy")
+	assert.Equal(t, "default-src 'self'; frame-ancestors https://partner.example.com", csp)
+	assert.Empty(t, nonce)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/widgets/", nil))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Contains(t, rec.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'")
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/widgets/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+}
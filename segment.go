@@ -0,0 +1,400 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// param is a single `{}` parameter definition within a route pattern. The
+// supported forms are:
+//
+//	{name}             a single path segment
+//	{name:pattern}     a value matching the regular expression `pattern`
+//	{name?}            an optional trailing segment
+//	{name?=default}    an optional trailing segment with a default value
+//	{name...}          the remainder of the path, including any `/`
+//	{name...?}         as above, but also matching when the `/` is omitted
+type param struct {
+	name string
+	// pattern constrains the values the parameter matches. It is empty for
+	// unconstrained parameters, which match a single path segment.
+	pattern string
+	// optional parameters may be omitted from the path, along with the `/`
+	// that precedes them, in which case def is used as their value.
+	optional bool
+	def      string
+	// catchAll parameters match the remainder of the path.
+	catchAll bool
+}
+
+// expr returns the regular expression that values of the parameter must match.
+func (p *param) expr() string {
+	switch {
+	case p.catchAll:
+		return ".*"
+	case p.pattern != "":
+		return p.pattern
+	}
+
+	return "[^/]+"
+}
+
+// piece is part of a segment: either literal text or a parameter.
+type piece struct {
+	literal string
+	param   *param
+}
+
+// segment is a single `/`-separated part of a route pattern.
+type segment struct {
+	raw    string
+	pieces []piece
+	// rx matches the segment if it contains any parameters, and is nil for
+	// static segments.
+	rx *regexp.Regexp
+}
+
+// only returns the segment's parameter if the segment consists of nothing but a
+// single parameter.
+func (s segment) only() *param {
+	if len(s.pieces) != 1 {
+		return nil
+	}
+
+	return s.pieces[0].param
+}
+
+func (s segment) optional() bool {
+	p := s.only()
+	return p != nil && p.optional
+}
+
+func (s segment) catchAll() bool {
+	p := s.only()
+	return p != nil && p.catchAll
+}
+
+// wildcard determines whether the segment matches any single path segment.
+func (s segment) wildcard() bool {
+	p := s.only()
+	return p != nil && !p.catchAll && p.pattern == ""
+}
+
+// Segment ranks, from most to least specific. When two routes match the same
+// request, the route whose first differing segment has the lower rank wins.
+const (
+	staticSegment = iota
+	constrainedSegment
+	unconstrainedSegment
+	catchAllSegment
+)
+
+// rank returns how specific the segment is.
+func (s segment) rank() int {
+	switch {
+	case s.rx == nil:
+		return staticSegment
+	case s.catchAll():
+		return catchAllSegment
+	case s.wildcard():
+		return unconstrainedSegment
+	}
+
+	return constrainedSegment
+}
+
+// parsePattern splits a route pattern into its segments. The pattern must start
+// with a `/`, so the first segment is always empty.
+func parsePattern(pattern string) ([]segment, error) {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range pattern {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("router: unbalanced braces in pattern %q", pattern)
+			}
+		case '/':
+			if depth == 0 {
+				parts = append(parts, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("router: unbalanced braces in pattern %q", pattern)
+	}
+	parts = append(parts, pattern[start:])
+
+	segs := make([]segment, len(parts))
+	for i, raw := range parts {
+		s, err := parseSegment(raw)
+		if err != nil {
+			return nil, fmt.Errorf("router: invalid pattern %q: %s", pattern, err)
+		}
+		segs[i] = s
+	}
+
+	for i, s := range segs {
+		for _, pc := range s.pieces {
+			if pc.param == nil {
+				continue
+			}
+			if (pc.param.optional || pc.param.catchAll) && s.only() == nil {
+				return nil, fmt.Errorf("router: invalid pattern %q: {%s} must be a whole segment",
+					pattern, pc.param.name)
+			}
+		}
+
+		if s.catchAll() && i != len(segs)-1 {
+			return nil, fmt.Errorf("router: invalid pattern %q: {%s...} must be the last segment",
+				pattern, s.only().name)
+		}
+
+		if i > 0 && segs[i-1].optional() && !s.optional() {
+			return nil, fmt.Errorf(
+				"router: invalid pattern %q: only trailing segments can be optional", pattern)
+		}
+	}
+
+	return segs, nil
+}
+
+// parseSegment splits a single segment into its literal and parameter pieces.
+func parseSegment(raw string) (segment, error) {
+	s := segment{raw: raw}
+
+	depth, start := 0, 0
+	for i, c := range raw {
+		switch c {
+		case '{':
+			if depth == 0 {
+				if i > start {
+					s.pieces = append(s.pieces, piece{literal: raw[start:i]})
+				}
+				start = i + 1
+			}
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p, err := parseParam(raw[start:i])
+				if err != nil {
+					return segment{}, err
+				}
+				s.pieces = append(s.pieces, piece{param: p})
+				start = i + 1
+			}
+		}
+	}
+	if start < len(raw) || len(s.pieces) == 0 {
+		s.pieces = append(s.pieces, piece{literal: raw[start:]})
+	}
+
+	if s.only() != nil || len(s.pieces) > 1 {
+		rx, err := regexp.Compile("^" + s.expr(false) + "$")
+		if err != nil {
+			return segment{}, err
+		}
+		s.rx = rx
+	}
+
+	return s, nil
+}
+
+// parseParam parses the contents of a single set of `{}` braces.
+func parseParam(spec string) (*param, error) {
+	p := &param{}
+
+	head := spec
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		head, p.pattern = spec[:i], spec[i+1:]
+	}
+
+	if i := strings.IndexByte(head, '?'); i >= 0 {
+		head, p.optional = head[:i], true
+		if rest := spec[i+1:]; rest != "" && rest[0] != ':' {
+			if rest[0] != '=' {
+				return nil, fmt.Errorf("unexpected %q after {%s?", rest, head)
+			}
+			p.def = strings.SplitN(rest[1:], ":", 2)[0]
+		}
+	}
+
+	if strings.HasSuffix(head, "...") {
+		head = strings.TrimSuffix(head, "...")
+		p.catchAll = true
+		if p.pattern != "" {
+			return nil, fmt.Errorf("catch-all {%s...} cannot have a pattern", head)
+		}
+	}
+
+	if head == "" {
+		return nil, fmt.Errorf("parameter {%s} has no name", spec)
+	}
+	p.name = head
+
+	return p, nil
+}
+
+// expr returns the regular expression for the segment, without the `/` that
+// precedes it. If capture is true, parameters are captured in named groups.
+func (s segment) expr(capture bool) string {
+	var b strings.Builder
+	for _, pc := range s.pieces {
+		switch {
+		case pc.param == nil:
+			b.WriteString(regexp.QuoteMeta(pc.literal))
+		case capture:
+			b.WriteString("(?P<" + pc.param.name + ">" + pc.param.expr() + ")")
+		default:
+			b.WriteString("(?:" + pc.param.expr() + ")")
+		}
+	}
+
+	return b.String()
+}
+
+// compilePattern builds the regular expression matching the full path of a
+// route with the given segments.
+func compilePattern(segs []segment) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+
+	optional := 0
+	for _, s := range segs[1:] {
+		if s.optional() {
+			b.WriteString("(?:")
+			optional++
+		}
+		b.WriteString("/" + s.expr(true))
+	}
+
+	b.WriteString(strings.Repeat(")?", optional) + "$")
+
+	return regexp.MustCompile(b.String())
+}
+
+// params returns every parameter defined in the segments, in order.
+func params(segs []segment) []*param {
+	var ps []*param
+	for _, s := range segs {
+		for _, pc := range s.pieces {
+			if pc.param != nil {
+				ps = append(ps, pc.param)
+			}
+		}
+	}
+
+	return ps
+}
+
+// variants expands any optional segments into each of the forms that a path
+// matching the segments can take.
+func variants(segs []segment) [][]segment {
+	n := len(segs)
+	for n > 0 && segs[n-1].optional() {
+		n--
+	}
+
+	var out [][]segment
+	for i := n; i <= len(segs); i++ {
+		out = append(out, segs[:i])
+	}
+
+	return out
+}
+
+// covers determines whether every path matched by `b` is also matched by `a`.
+func covers(a []segment, b []segment) bool {
+	for _, bv := range variants(b) {
+		covered := false
+		for _, av := range variants(a) {
+			if coversVariant(av, bv) {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			return false
+		}
+	}
+
+	return true
+}
+
+func coversVariant(a []segment, b []segment) bool {
+	for i := range a {
+		if a[i].catchAll() {
+			return len(b) > i
+		}
+
+		if i >= len(b) || b[i].catchAll() {
+			return false
+		}
+
+		switch {
+		case b[i].rx == nil:
+			if a[i].rx == nil && a[i].raw != b[i].raw {
+				return false
+			}
+			if a[i].rx != nil && !a[i].rx.MatchString(b[i].raw) {
+				return false
+			}
+		case a[i].rx == nil:
+			return false
+		case a[i].wildcard():
+		default:
+			if a[i].rx.String() != b[i].rx.String() {
+				return false
+			}
+		}
+	}
+
+	return len(a) == len(b)
+}
+
+// overlaps determines whether a path may be matched by both `a` and `b`.
+func overlaps(a []segment, b []segment) bool {
+	for _, av := range variants(a) {
+		for _, bv := range variants(b) {
+			if overlapsVariant(av, bv) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func overlapsVariant(a []segment, b []segment) bool {
+	for i := 0; ; i++ {
+		if i >= len(a) || i >= len(b) {
+			return len(a) == len(b)
+		}
+
+		if a[i].catchAll() || b[i].catchAll() {
+			return true
+		}
+
+		switch {
+		case a[i].rx == nil && b[i].rx == nil:
+			if a[i].raw != b[i].raw {
+				return false
+			}
+		case a[i].rx == nil:
+			if !b[i].rx.MatchString(a[i].raw) {
+				return false
+			}
+		case b[i].rx == nil:
+			if !a[i].rx.MatchString(b[i].raw) {
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// maxCookieSize is the largest cookie value browsers are guaranteed to store.
+const maxCookieSize = 4096
+
+var errInvalidCookie = errors.New("sessions: invalid cookie")
+
+// seal encrypts and authenticates data with key, using AES-GCM. The cookie name
+// is authenticated too, so a value can't be moved between cookies.
+func seal(key []byte, name string, data []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, data, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value sealed with any of the keys.
+func open(keys [][]byte, name string, value string) ([]byte, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCookie
+	}
+
+	for _, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(sealed) < aead.NonceSize() {
+			return nil, errInvalidCookie
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if data, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return data, nil
+		}
+	}
+
+	return nil, errInvalidCookie
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
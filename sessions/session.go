@@ -0,0 +1,161 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Session holds the values stored for a single client between requests. It is
+// safe for concurrent use.
+type Session struct {
+	mu sync.Mutex
+
+	id      string
+	values  map[string]string
+	flashes []string
+	created time.Time
+
+	// previous is the ID the session was loaded with, if it has been
+	// regenerated since.
+	previous string
+	modified bool
+}
+
+// record is the form sessions are stored in.
+type record struct {
+	ID      string            `json:"id"`
+	Values  map[string]string `json:"values,omitempty"`
+	Flashes []string          `json:"flashes,omitempty"`
+	Created int64             `json:"created"`
+	Seen    int64             `json:"seen"`
+}
+
+// Get returns the session for the request, or nil if the request wasn't served
+// by the session middleware.
+func Get(r *http.Request) *Session {
+	s, _ := r.Context().Value(contextKey{}).(*Session)
+	return s
+}
+
+func newSession(now time.Time) *Session {
+	return &Session{id: newID(), values: map[string]string{}, created: now}
+}
+
+// ID returns the session's ID.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.id
+}
+
+// Get returns the value stored under key, or an empty string if there is none.
+func (s *Session) Get(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.values[key]
+}
+
+// Set stores value under key.
+func (s *Session) Set(key string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+	s.modified = true
+}
+
+// AddFlash adds a message to be shown on the next page the client views, such
+// as a confirmation after a form is submitted.
+func (s *Session) AddFlash(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flashes = append(s.flashes, message)
+	s.modified = true
+}
+
+// Flashes returns the flash messages added to the session, and removes them so
+// that they are only shown once.
+func (s *Session) Flashes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flashes := s.flashes
+	if len(flashes) > 0 {
+		s.flashes = nil
+		s.modified = true
+	}
+
+	return flashes
+}
+
+// Regenerate gives the session a new ID, keeping its values. Call it when a user
+// logs in or their privileges change, so that an ID planted by an attacker
+// before login can't be used to take over the session.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.previous == "" {
+		s.previous = s.id
+	}
+	s.id = newID()
+	s.modified = true
+}
+
+// Destroy removes the session and all of its values, such as when a user logs
+// out. If values are stored in the session afterwards, they are saved under a
+// new ID.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.previous == "" {
+		s.previous = s.id
+	}
+	s.id = newID()
+	s.values = map[string]string{}
+	s.flashes = nil
+	s.modified = true
+}
+
+// empty determines whether the session holds anything worth saving. Callers must
+// hold s.mu.
+func (s *Session) empty() bool {
+	return len(s.values) == 0 && len(s.flashes) == 0
+}
+
+func (s *Session) record(now time.Time) record {
+	return record{
+		ID:      s.id,
+		Values:  s.values,
+		Flashes: s.flashes,
+		Created: s.created.Unix(),
+		Seen:    now.Unix(),
+	}
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
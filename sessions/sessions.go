@@ -0,0 +1,264 @@
+// Package sessions provides session middleware for the router. Sessions are
+// stored in encrypted cookies, or on the server in a Store with only the session
+// ID kept in a cookie. The middleware can be added to the router, a Group or a
+// single Route like any other middleware, and handlers read the session with
+// Get.
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gostalt/router"
+)
+
+// Config configures sessions.
+type Config struct {
+	// Keys encrypt and authenticate session cookies, and must be 16, 24 or 32
+	// bytes long. New cookies are encrypted with the first key, and cookies
+	// encrypted with any of the keys are accepted, so keys can be rotated.
+	// Keys are required unless a Store is set.
+	Keys [][]byte
+
+	// Store holds sessions on the server. If it is nil, sessions are stored in
+	// the cookie itself.
+	Store Store
+
+	// Cookie is the name of the session cookie. It defaults to "session".
+	Cookie string
+	Path   string
+	Domain string
+	// SameSite defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// Insecure stops the cookie from being marked Secure, so that sessions work
+	// over plain HTTP during development.
+	Insecure bool
+
+	// IdleTimeout ends sessions that haven't been used for the given time. It
+	// defaults to 30 minutes.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout ends sessions once they are the given age, however
+	// recently they have been used. It defaults to 24 hours.
+	AbsoluteTimeout time.Duration
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// Middleware returns middleware that loads the session for each request, and
+// saves it before the response is written.
+func Middleware(c Config) router.Middleware {
+	if c.Store == nil && len(c.Keys) == 0 {
+		panic("sessions: Keys are required for cookie sessions")
+	}
+	for _, key := range c.Keys {
+		if _, err := newAEAD(key); err != nil {
+			panic("sessions: invalid key: " + err.Error())
+		}
+	}
+	if c.Cookie == "" {
+		c.Cookie = "session"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = 30 * time.Minute
+	}
+	if c.AbsoluteTimeout == 0 {
+		c.AbsoluteTimeout = 24 * time.Hour
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, loaded := c.load(r)
+
+			sw := &saveWriter{ResponseWriter: w, save: func() {
+				if err := c.save(w, r, s, loaded); err != nil {
+					router.ReportError(r, fmt.Errorf("sessions: %w", err))
+				}
+			}}
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, s)))
+			sw.commit()
+		})
+	}
+}
+
+// load returns the session for the request. loaded is false if the request had
+// no valid session, and a new one was started. Errors from the store are
+// reported, so that an outage doesn't look like clients losing their sessions.
+func (c Config) load(r *http.Request) (s *Session, loaded bool) {
+	now := c.Now()
+
+	cookie, err := r.Cookie(c.Cookie)
+	if err != nil {
+		return newSession(now), false
+	}
+
+	var data []byte
+	if c.Store != nil {
+		if data, err = c.Store.Load(r.Context(), cookie.Value); err != nil {
+			router.ReportError(r, fmt.Errorf("sessions: %w", err))
+		}
+	} else {
+		data, err = open(c.Keys, c.Cookie, cookie.Value)
+	}
+	if err != nil || data == nil {
+		return newSession(now), false
+	}
+
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return newSession(now), false
+	}
+
+	if c.Store != nil && rec.ID != cookie.Value {
+		return newSession(now), false
+	}
+
+	created, seen := time.Unix(rec.Created, 0), time.Unix(rec.Seen, 0)
+	if now.Sub(seen) > c.IdleTimeout || now.Sub(created) > c.AbsoluteTimeout {
+		if c.Store != nil {
+			if err := c.Store.Delete(r.Context(), rec.ID); err != nil {
+				router.ReportError(r, fmt.Errorf("sessions: %w", err))
+			}
+		}
+		return newSession(now), false
+	}
+
+	if rec.Values == nil {
+		rec.Values = map[string]string{}
+	}
+
+	return &Session{
+		id:      rec.ID,
+		values:  rec.Values,
+		flashes: rec.Flashes,
+		created: created,
+	}, true
+}
+
+// save writes the session to its cookie, and to the store if there is one. New
+// sessions are only saved once they hold values, and loaded sessions are saved
+// on every request so that their idle timeout is extended.
+func (c Config) save(w http.ResponseWriter, r *http.Request, s *Session, loaded bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := r.Context()
+	now := c.Now()
+
+	if c.Store != nil && s.previous != "" {
+		if err := c.Store.Delete(ctx, s.previous); err != nil {
+			return err
+		}
+	}
+
+	if s.empty() {
+		if !loaded {
+			return nil
+		}
+		if c.Store != nil {
+			if err := c.Store.Delete(ctx, s.id); err != nil {
+				return err
+			}
+		}
+
+		http.SetCookie(w, c.cookie("", -1))
+		return nil
+	}
+
+	if !loaded && !s.modified {
+		return nil
+	}
+
+	data, err := json.Marshal(s.record(now))
+	if err != nil {
+		return err
+	}
+
+	value := s.id
+	if c.Store != nil {
+		ttl := c.IdleTimeout
+		if remaining := c.AbsoluteTimeout - now.Sub(s.created); remaining < ttl {
+			ttl = remaining
+		}
+		if err := c.Store.Save(ctx, s.id, data, ttl); err != nil {
+			return err
+		}
+	} else {
+		value, err = seal(c.Keys[0], c.Cookie, data)
+		if err != nil {
+			return err
+		}
+		if len(value) > maxCookieSize {
+			return fmt.Errorf("session cookie is %d bytes, over the limit of %d", len(value), maxCookieSize)
+		}
+	}
+
+	http.SetCookie(w, c.cookie(value, 0))
+	return nil
+}
+
+func (c Config) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Cookie,
+		Value:    value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   maxAge,
+		Secure:   !c.Insecure,
+		HttpOnly: true,
+		SameSite: c.SameSite,
+	}
+}
+
+// saveWriter saves the session just before the response is started, while its
+// cookie can still be set.
+type saveWriter struct {
+	http.ResponseWriter
+	save  func()
+	saved bool
+}
+
+func (w *saveWriter) commit() {
+	if !w.saved {
+		w.saved = true
+		w.save()
+	}
+}
+
+func (w *saveWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.commit()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *saveWriter) Write(b []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *saveWriter) Flush() {
+	w.commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for use by
+// http.ResponseController.
+func (w *saveWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
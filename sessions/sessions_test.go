@@ -0,0 +1,272 @@
+package sessions_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/gostalt/router/sessions"
+	"github.com/stretchr/testify/assert"
+)
+
+var key = []byte("0123456789abcdef0123456789abcdef")
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// client sends requests to a router, keeping the session cookie between them.
+type client struct {
+	r      *router.Router
+	cookie *http.Cookie
+}
+
+func (c *client) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if c.cookie != nil {
+		req.AddCookie(c.cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	c.r.ServeHTTP(rec, req)
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			c.cookie = nil
+		} else {
+			c.cookie = cookie
+		}
+	}
+
+	return rec
+}
+
+func sessionRouter(c sessions.Config) *router.Router {
+	r := router.New().Middleware(sessions.Middleware(c))
+	r.Get("set/{value}", func(req *http.Request) string {
+		sessions.Get(req).Set("name", router.Param(req, "value"))
+		return "ok"
+	})
+	r.Get("show", func(req *http.Request) string {
+		return sessions.Get(req).Get("name")
+	})
+	r.Get("id", func(req *http.Request) string {
+		return sessions.Get(req).ID()
+	})
+	r.Get("flash", func(req *http.Request) string {
+		sessions.Get(req).AddFlash("saved")
+		return "ok"
+	})
+	r.Get("flashes", func(req *http.Request) string {
+		return strings.Join(sessions.Get(req).Flashes(), ",")
+	})
+	r.Get("login", func(req *http.Request) string {
+		s := sessions.Get(req)
+		s.Regenerate()
+		s.Set("user", "42")
+		return s.ID()
+	})
+	r.Get("logout", func(req *http.Request) string {
+		sessions.Get(req).Destroy()
+		return "ok"
+	})
+
+	return r
+}
+
+func TestCookieSessions(t *testing.T) {
+	c := &client{r: sessionRouter(sessions.Config{Keys: [][]byte{key}})}
+
+	c.get("/show")
+	assert.Nil(t, c.cookie)
+
+	c.get("/set/frank")
+	if assert.NotNil(t, c.cookie) {
+		assert.Equal(t, "session", c.cookie.Name)
+		assert.True(t, c.cookie.Secure)
+		assert.True(t, c.cookie.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, c.cookie.SameSite)
+		assert.NotContains(t, c.cookie.Value, "frank")
+	}
+	assert.Equal(t, "frank", c.get("/show").Body.String())
+
+	value := c.cookie.Value[:len(c.cookie.Value)-2] + "AA"
+	tampered := &client{r: c.r, cookie: &http.Cookie{Name: "session", Value: value}}
+	assert.Empty(t, tampered.get("/show").Body.String())
+
+	c.get("/logout")
+	assert.Nil(t, c.cookie)
+	assert.Empty(t, c.get("/show").Body.String())
+}
+
+func TestCookieSessionKeyRotation(t *testing.T) {
+	old := &client{r: sessionRouter(sessions.Config{Keys: [][]byte{key}})}
+	old.get("/set/frank")
+
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+	rotated := &client{
+		r:      sessionRouter(sessions.Config{Keys: [][]byte{newKey, key}}),
+		cookie: old.cookie,
+	}
+	assert.Equal(t, "frank", rotated.get("/show").Body.String())
+
+	retired := &client{r: sessionRouter(sessions.Config{Keys: [][]byte{newKey}}), cookie: old.cookie}
+	assert.Empty(t, retired.get("/show").Body.String())
+}
+
+func TestStoreSessions(t *testing.T) {
+	store := sessions.NewMemoryStore()
+	c := &client{r: sessionRouter(sessions.Config{Store: store})}
+
+	c.get("/set/frank")
+	id := c.get("/id").Body.String()
+	assert.Equal(t, id, c.cookie.Value)
+	assert.Equal(t, "frank", c.get("/show").Body.String())
+
+	stolen := &http.Cookie{Name: "session", Value: id}
+
+	newID := c.get("/login").Body.String()
+	assert.NotEqual(t, id, newID)
+	assert.Equal(t, newID, c.cookie.Value)
+	assert.Equal(t, "frank", c.get("/show").Body.String())
+
+	attacker := &client{r: c.r, cookie: stolen}
+	assert.Empty(t, attacker.get("/show").Body.String())
+
+	c.get("/logout")
+	attacker = &client{r: c.r, cookie: &http.Cookie{Name: "session", Value: newID}}
+	assert.Empty(t, attacker.get("/show").Body.String())
+}
+
+func TestFlashes(t *testing.T) {
+	c := &client{r: sessionRouter(sessions.Config{Keys: [][]byte{key}})}
+
+	c.get("/flash")
+	assert.Equal(t, "saved", c.get("/flashes").Body.String())
+	assert.Empty(t, c.get("/flashes").Body.String())
+}
+
+func TestSessionTimeouts(t *testing.T) {
+	clk := newClock()
+	c := &client{r: sessionRouter(sessions.Config{
+		Store:           sessions.NewMemoryStore(),
+		IdleTimeout:     10 * time.Minute,
+		AbsoluteTimeout: time.Hour,
+		Now:             clk.Now,
+	})}
+
+	c.get("/set/frank")
+	clk.Advance(9 * time.Minute)
+	assert.Equal(t, "frank", c.get("/show").Body.String())
+	clk.Advance(11 * time.Minute)
+	assert.Empty(t, c.get("/show").Body.String())
+
+	c.get("/set/frank")
+	for i := 0; i < 6; i++ {
+		clk.Advance(9 * time.Minute)
+		assert.Equal(t, "frank", c.get("/show").Body.String())
+	}
+	clk.Advance(9 * time.Minute)
+	assert.Empty(t, c.get("/show").Body.String())
+}
+
+type failingStore struct {
+	*sessions.MemoryStore
+}
+
+func (failingStore) Save(context.Context, string, []byte, time.Duration) error {
+	return errors.New("store unavailable")
+}
+
+func TestStoreErrorsAreReported(t *testing.T) {
+	var reported error
+	r := sessionRouter(sessions.Config{
+		Keys:  [][]byte{key},
+		Store: failingStore{sessions.NewMemoryStore()},
+	}).OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	c := &client{r: r}
+
+	assert.Equal(t, "ok", c.get("/set/ada").Body.String())
+	assert.EqualError(t, reported, "sessions: store unavailable")
+}
+
+// flakyStore is a store whose loads and deletes can be made to fail.
+type flakyStore struct {
+	*sessions.MemoryStore
+	failLoad, failDelete bool
+}
+
+func (s *flakyStore) Load(ctx context.Context, id string) ([]byte, error) {
+	if s.failLoad {
+		return nil, errors.New("store unavailable")
+	}
+	return s.MemoryStore.Load(ctx, id)
+}
+
+func (s *flakyStore) Delete(ctx context.Context, id string) error {
+	if s.failDelete {
+		return errors.New("store unavailable")
+	}
+	return s.MemoryStore.Delete(ctx, id)
+}
+
+func TestStoreLoadAndDeleteErrorsAreReported(t *testing.T) {
+	clk := newClock()
+	store := &flakyStore{MemoryStore: sessions.NewMemoryStore()}
+
+	var reported error
+	r := sessionRouter(sessions.Config{
+		Keys:        [][]byte{key},
+		Store:       store,
+		IdleTimeout: 10 * time.Minute,
+		Now:         clk.Now,
+	}).OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	c := &client{r: r}
+	c.get("/set/ada")
+	assert.NoError(t, reported)
+
+	store.failLoad = true
+	cookie := c.cookie
+	assert.Empty(t, c.get("/show").Body.String())
+	assert.EqualError(t, reported, "sessions: store unavailable")
+
+	store.failLoad, store.failDelete, reported = false, true, nil
+	c.cookie = cookie
+	clk.Advance(time.Hour)
+	assert.Empty(t, c.get("/show").Body.String())
+	assert.EqualError(t, reported, "sessions: store unavailable")
+}
+
+func TestSessionsWithoutMiddleware(t *testing.T) {
+	assert.Nil(t, sessions.Get(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestSessionConfigIsChecked(t *testing.T) {
+	assert.Panics(t, func() {
+		sessions.Middleware(sessions.Config{})
+	})
+	assert.Panics(t, func() {
+		sessions.Middleware(sessions.Config{Keys: [][]byte{[]byte("short")}})
+	})
+}
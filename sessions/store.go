@@ -0,0 +1,80 @@
+package sessions
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store holds sessions on the server, keyed by session ID. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Load returns the data saved for the session with the given ID, or nil if
+	// there is none.
+	Load(ctx context.Context, id string) ([]byte, error)
+	// Save saves data for the session with the given ID, replacing any that was
+	// saved before. The data may be discarded once ttl has passed.
+	Save(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete removes the session with the given ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store that keeps sessions in memory. Sessions are not shared
+// between processes, and are lost when the process exits.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	saves   int
+}
+
+type entry struct {
+	data    []byte
+	expires time.Time
+}
+
+// sweepEvery is the number of saves between sweeps for expired sessions.
+const sweepEvery = 1000
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]entry{}}
+}
+
+func (s *MemoryStore) Load(ctx context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok || time.Now().After(e.expires) {
+		return nil, nil
+	}
+
+	return e.data, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	s.saves++
+	if s.saves%sweepEvery == 0 {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	s.entries[id] = entry{data: append([]byte(nil), data...), expires: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
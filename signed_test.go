@@ -0,0 +1,125 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedURL(t *testing.T) {
+	r := router.New().SigningKeys([]byte("secret"))
+	r.Get("unsubscribe/{user}", func(req *http.Request) string {
+		return "unsubscribed " + router.Param(req, "user")
+	}).Name("unsubscribe").Middleware(router.ValidateSignature)
+
+	u, err := r.SignedURL("unsubscribe", map[string]string{"user": "42", "list": "news"}, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(u, "/unsubscribe/42?"))
+	assert.Contains(t, u, "expires=")
+	assert.Contains(t, u, "signature=")
+
+	rec := record(r, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "unsubscribed 42", rec.Body.String())
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, strings.Replace(u, "/42?", "/43?", 1), nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "403 invalid signature", rec.Body.String())
+
+	altered := strings.Replace(u, "list=news", "list=offers", 1)
+	rec = record(r, httptest.NewRequest(http.MethodGet, altered, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, u+"&extra=1", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = record(r, httptest.NewRequest(http.MethodGet, "/unsubscribe/42", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSignedURLExpiry(t *testing.T) {
+	r := router.New().SigningKeys([]byte("secret"))
+	r.Get("unsubscribe/{user}", func(req *http.Request) string {
+		return "unsubscribed " + router.Param(req, "user")
+	}).Name("unsubscribe").Middleware(router.ValidateSignature)
+
+	expired, err := r.SignedURL("unsubscribe", map[string]string{"user": "42"}, -time.Minute)
+	assert.NoError(t, err)
+	rec := record(r, httptest.NewRequest(http.MethodGet, expired, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	permanent, err := r.SignedURL("unsubscribe", map[string]string{"user": "42"}, 0)
+	assert.NoError(t, err)
+	assert.NotContains(t, permanent, "expires=")
+	rec = record(r, httptest.NewRequest(http.MethodGet, permanent, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	u, _ := url.Parse(permanent)
+	q := u.Query()
+	q.Set("expires", "9999999999")
+	u.RawQuery = q.Encode()
+	rec = record(r, httptest.NewRequest(http.MethodGet, u.String(), nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSignedURLKeyRotation(t *testing.T) {
+	r := router.New().SigningKeys([]byte("old"))
+	r.Get("unsubscribe/{user}", func(req *http.Request) string {
+		return "unsubscribed " + router.Param(req, "user")
+	}).Name("unsubscribe").Middleware(router.ValidateSignature)
+
+	u, err := r.SignedURL("unsubscribe", map[string]string{"user": "42"}, time.Hour)
+	assert.NoError(t, err)
+
+	r.SigningKeys([]byte("new"), []byte("old"))
+	rec := record(r, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	fresh, err := r.SignedURL("unsubscribe", map[string]string{"user": "42"}, time.Hour)
+	assert.NoError(t, err)
+
+	r.SigningKeys([]byte("old"))
+	rec = record(r, httptest.NewRequest(http.MethodGet, fresh, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	r.SigningKeys([]byte("new"))
+	rec = record(r, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSignedURLErrors(t *testing.T) {
+	r := router.New()
+	r.Get("unsubscribe/{user}", func(req *http.Request) string {
+		return "unsubscribed " + router.Param(req, "user")
+	}).Name("unsubscribe").Middleware(router.ValidateSignature)
+
+	_, err := r.SignedURL("unsubscribe", map[string]string{"user": "42"}, time.Hour)
+	assert.EqualError(t, err, "router: no signing keys have been set")
+
+	_, err = r.SigningKeys([]byte("secret")).SignedURL("missing", nil, time.Hour)
+	assert.EqualError(t, err, `router: no route named "missing"`)
+}
+
+func TestSignedURLForMountedRoute(t *testing.T) {
+	r := router.New().SigningKeys([]byte("secret"))
+	r.Get("unsubscribe/{user}", func(req *http.Request) string {
+		return "unsubscribed " + router.Param(req, "user")
+	}).Name("unsubscribe").Middleware(router.ValidateSignature)
+
+	rtr := router.New().SigningKeys([]byte("secret"))
+	rtr.Mount("/mail", r)
+
+	u, err := rtr.SignedURL("unsubscribe", map[string]string{"user": "42"}, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(u, "/mail/unsubscribe/42?"))
+
+	rec := record(rtr, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "unsubscribed 42", rec.Body.String())
+}
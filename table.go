@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// table is an immutable snapshot of the routes registered on a Router. Requests
+// are matched against the most recently published table, so routes can be added
+// while the router is serving without in-flight requests seeing a partial change.
+// Routes in the table are ordered by precedence.
+type table struct {
+	routes []*Route
+
+	slashes SlashPolicy
+	clean   bool
+
+	validators []Validator
+
+	onError func(*http.Request, error)
+
+	signingKeys [][]byte
+
+	maintenance *maintenance
+
+	// versioned is true if any route in the table belongs to an API version.
+	versioned      bool
+	versioning     VersionStrategy
+	defaultVersion string
+
+	validated sync.Once
+	err       error
+}
+
+// update runs fn while holding the router's write lock, then publishes a new
+// route table reflecting whatever fn changed.
+func (router *Router) update(fn func()) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	fn()
+	router.publish()
+}
+
+// publish rebuilds the route table from the router's groups and atomically swaps
+// it in. Callers must hold router.mu.
+func (router *Router) publish() {
+	t := &table{
+		slashes: router.slashes,
+		clean:   router.clean,
+
+		validators:     append([]Validator(nil), router.validators...),
+		versioning:     router.versioning,
+		defaultVersion: router.defaultVersion,
+		onError:        router.onError,
+		signingKeys:    router.signingKeys,
+		maintenance:    router.maintenance,
+	}
+
+	for _, group := range router.groups {
+		for _, route := range group.routes {
+			frozen := route.freeze()
+			if router.caseInsensitive {
+				frozen.regex = frozen.caselessRegex
+			}
+			t.routes = append(t.routes, frozen)
+			t.versioned = t.versioned || frozen.version != ""
+		}
+	}
+
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		return compare(t.routes[i], t.routes[j]) < 0
+	})
+
+	router.table.Store(t)
+}
+
+// routes returns the route table currently being served.
+func (router *Router) routes() *table {
+	t, ok := router.table.Load().(*table)
+	if !ok {
+		return &table{}
+	}
+
+	return t
+}
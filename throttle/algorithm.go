@@ -0,0 +1,113 @@
+package throttle
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// tokenBucket implements TokenBucket. Its state is the number of tokens left in
+// the bucket and the time the bucket was last updated.
+func tokenBucket(c Config, state []byte, now time.Time) ([]byte, Result) {
+	capacity := float64(c.Limit)
+	rate := capacity / float64(c.Period)
+
+	tokens, updated := capacity, now
+	if len(state) == 16 {
+		tokens = math.Float64frombits(binary.BigEndian.Uint64(state[:8]))
+		updated = time.Unix(0, int64(binary.BigEndian.Uint64(state[8:])))
+	}
+
+	if elapsed := now.Sub(updated); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+float64(elapsed)*rate)
+	}
+
+	res := Result{Limit: c.Limit}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - tokens) / rate)
+	}
+
+	res.Remaining = int(tokens)
+	res.Reset = time.Duration((capacity - tokens) / rate)
+
+	next := make([]byte, 16)
+	binary.BigEndian.PutUint64(next[:8], math.Float64bits(tokens))
+	binary.BigEndian.PutUint64(next[8:], uint64(now.UnixNano()))
+
+	return next, res
+}
+
+// slidingWindow implements SlidingWindow. Its state is the start of the current
+// fixed window and the number of requests in it and in the previous window.
+func slidingWindow(c Config, state []byte, now time.Time) ([]byte, Result) {
+	start := now.Truncate(c.Period)
+
+	var current, previous int64
+	if len(state) == 24 {
+		storedStart := time.Unix(0, int64(binary.BigEndian.Uint64(state[:8])))
+		storedCurrent := int64(binary.BigEndian.Uint64(state[8:16]))
+		storedPrevious := int64(binary.BigEndian.Uint64(state[16:]))
+
+		switch {
+		case storedStart.Equal(start):
+			current, previous = storedCurrent, storedPrevious
+		case storedStart.Equal(start.Add(-c.Period)):
+			previous = storedCurrent
+		}
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(c.Period)
+	estimate := float64(previous)*weight + float64(current)
+
+	res := Result{Limit: c.Limit, Reset: c.Period - elapsed}
+	if estimate+1 <= float64(c.Limit) {
+		current++
+		estimate++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = retryAfter(c, previous, current, elapsed)
+	}
+
+	// Requests in the current window are still counted, with a decreasing
+	// weight, throughout the next window.
+	res.Remaining = int(math.Max(0, float64(c.Limit)-estimate))
+	if current > 0 {
+		res.Reset += c.Period
+	}
+
+	next := make([]byte, 24)
+	binary.BigEndian.PutUint64(next[:8], uint64(start.UnixNano()))
+	binary.BigEndian.PutUint64(next[8:16], uint64(current))
+	binary.BigEndian.PutUint64(next[16:], uint64(previous))
+
+	return next, res
+}
+
+// retryAfter returns how long until the sliding window will allow another
+// request. Within the current window, the estimate only falls as the previous
+// window's requests are weighted less; otherwise, the client must wait until
+// the next window, where the current count becomes the previous one.
+func retryAfter(c Config, previous int64, current int64, elapsed time.Duration) time.Duration {
+	limit := float64(c.Limit)
+	if previous > 0 && float64(current)+1 <= limit {
+		// Solve previous * (1 - t/period) + current + 1 <= limit for t.
+		t := (1 - (limit-float64(current)-1)/float64(previous)) * float64(c.Period)
+		if d := time.Duration(t) - elapsed; d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	remaining := c.Period - elapsed
+	if float64(current)+1 <= limit {
+		return remaining
+	}
+
+	// In the next window, current becomes the previous count.
+	t := (1 - (limit-1)/float64(current)) * float64(c.Period)
+	return remaining + time.Duration(t)
+}
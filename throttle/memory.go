@@ -0,0 +1,59 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps the state of limits in memory. Limits are
+// not shared between processes.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	updates int
+}
+
+type entry struct {
+	state   []byte
+	expires time.Time
+}
+
+// sweepEvery is the number of updates between sweeps for expired entries.
+const sweepEvery = 1000
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]entry{}}
+}
+
+func (s *MemoryStore) Update(
+	ctx context.Context, key string, ttl time.Duration, fn func(state []byte) ([]byte, error),
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	s.updates++
+	if s.updates%sweepEvery == 0 {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	var state []byte
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		state = e.state
+	}
+
+	next, err := fn(state)
+	if err != nil {
+		return err
+	}
+
+	s.entries[key] = entry{state: next, expires: now.Add(ttl)}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package throttle provides rate limiting middleware for the router. Limits are
+// applied like any other middleware, so they can be set for the whole router,
+// a Group or a single Route.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gostalt/router"
+)
+
+// KeyFunc returns the key a request is counted against. Requests with the same
+// key share a limit.
+type KeyFunc func(r *http.Request) string
+
+// ByIP counts requests by the IP address of the client.
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ByRoute counts requests by the route they matched, using the route's name if
+// it has one, or its pattern otherwise. All clients share the limit.
+func ByRoute(r *http.Request) string {
+	route := router.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+
+	if name := route.GetName(); name != "" {
+		return name
+	}
+
+	return route.Pattern()
+}
+
+// ByUser counts requests by the user returned by `user`. Requests without a
+// user are counted by IP address.
+func ByUser(user func(r *http.Request) string) KeyFunc {
+	return func(r *http.Request) string {
+		if u := user(r); u != "" {
+			return "user:" + u
+		}
+
+		return "ip:" + ByIP(r)
+	}
+}
+
+// ByHeader counts requests by the value of a request header, such as an API
+// key or the client address set by a trusted proxy.
+func ByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// Keys combines key functions, so that requests are counted against each
+// combination of their keys, such as per user and per route.
+func Keys(fns ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		key := ""
+		for i, fn := range fns {
+			if i > 0 {
+				key += "|"
+			}
+			key += fn(r)
+		}
+
+		return key
+	}
+}
+
+// Config configures a rate limit.
+type Config struct {
+	// Limit is the number of requests allowed each Period.
+	Limit  int
+	Period time.Duration
+
+	// Key determines which requests share a limit. It defaults to ByIP.
+	Key KeyFunc
+
+	// Store holds the state of the limit. It defaults to a new MemoryStore.
+	Store Store
+
+	// Name separates the keys of this limit from others in a shared Store. It
+	// defaults to a name derived from the algorithm, Limit and Period.
+	Name string
+
+	// Denied writes the response to requests that exceed the limit. It
+	// defaults to a plain 429 Too Many Requests response.
+	Denied http.Handler
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// Result is the outcome of counting a request against a limit.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the limit is fully replenished.
+	Reset time.Duration
+	// RetryAfter is the time until a denied request would be allowed.
+	RetryAfter time.Duration
+}
+
+// algorithm counts a request against a limit, given the limit's current state,
+// and returns the new state.
+type algorithm func(c Config, state []byte, now time.Time) ([]byte, Result)
+
+// TokenBucket limits requests with a token bucket. Clients can make up to
+// Limit requests at once, and regain the ability to make a request at an even
+// rate of Limit requests per Period.
+func TokenBucket(c Config) router.Middleware {
+	return middleware("token-bucket", c, tokenBucket, c.Period)
+}
+
+// SlidingWindow limits requests to Limit in any Period, by weighting the count
+// of requests in the previous fixed window by how much of it overlaps the
+// sliding window.
+func SlidingWindow(c Config) router.Middleware {
+	return middleware("sliding-window", c, slidingWindow, 2*c.Period)
+}
+
+func middleware(kind string, c Config, count algorithm, ttl time.Duration) router.Middleware {
+	if c.Limit <= 0 || c.Period <= 0 {
+		panic("throttle: Limit and Period must be positive")
+	}
+	if c.Key == nil {
+		c.Key = ByIP
+	}
+	if c.Store == nil {
+		c.Store = NewMemoryStore()
+	}
+	if c.Name == "" {
+		c.Name = fmt.Sprintf("%s:%d/%s", kind, c.Limit, c.Period)
+	}
+	if c.Denied == nil {
+		c.Denied = http.HandlerFunc(tooManyRequests)
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", c.Limit, int(math.Ceil(c.Period.Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var res Result
+			now := c.Now()
+			err := c.Store.Update(r.Context(), c.Name+":"+c.Key(r), ttl, func(state []byte) ([]byte, error) {
+				var next []byte
+				next, res = count(c, state, now)
+				return next, nil
+			})
+
+			// Fail open, so an unavailable store doesn't take the application
+			// down with it.
+			if err != nil {
+				router.ReportError(r, fmt.Errorf("throttle: %w", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", policy)
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", seconds(res.Reset))
+
+			if !res.Allowed {
+				h.Set("Retry-After", seconds(res.RetryAfter))
+				c.Denied.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "429 too many requests", http.StatusTooManyRequests)
+}
+
+// seconds formats a duration as a whole number of seconds, rounding up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Store holds the state of rate limits. Implementations backed by a shared
+// database allow limits to be enforced across several application instances.
+type Store interface {
+	// Update atomically replaces the state stored under key with the state
+	// returned by fn. fn is passed the current state, or nil if there is none,
+	// and may be called more than once if the store retries the update after a
+	// concurrent change. The new state may be discarded once ttl has passed
+	// without an update.
+	Update(
+		ctx context.Context, key string, ttl time.Duration, fn func(state []byte) ([]byte, error),
+	) error
+}
@@ -0,0 +1,141 @@
+package throttle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/gostalt/router/throttle"
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func serve(h http.Handler, remote string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remote
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := newClock()
+	r := router.New()
+	r.Get("/", func() string { return "ok" }).Middleware(throttle.TokenBucket(throttle.Config{
+		Limit:  2,
+		Period: time.Minute,
+		Now:    c.Now,
+	}))
+
+	rec := serve(r, "1.1.1.1:1000")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, serve(r, "1.1.1.1:1000").Code)
+
+	rec = serve(r, "1.1.1.1:1000")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	// Other clients have their own bucket.
+	assert.Equal(t, http.StatusOK, serve(r, "2.2.2.2:1000").Code)
+
+	c.Advance(30 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(r, "1.1.1.1:1000").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(r, "1.1.1.1:1000").Code)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := newClock()
+	r := router.New()
+	r.Group(
+		router.Get("/", func() string { return "ok" }),
+	).Middleware(throttle.SlidingWindow(throttle.Config{
+		Limit:  4,
+		Period: time.Minute,
+		Key:    throttle.ByRoute,
+		Now:    c.Now,
+	}))
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, http.StatusOK, serve(r, "1.1.1.1:1000").Code)
+	}
+
+	// The limit is per route, so other clients are included.
+	rec := serve(r, "2.2.2.2:1000")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "75", rec.Header().Get("Retry-After"))
+
+	// Halfway through the next window, half of the previous window's requests
+	// still count.
+	c.Advance(90 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(r, "1.1.1.1:1000").Code)
+	rec = serve(r, "1.1.1.1:1000")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(r, "1.1.1.1:1000").Code)
+}
+
+func TestKeys(t *testing.T) {
+	key := throttle.Keys(throttle.ByUser(func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	}), throttle.ByHeader("X-Tenant"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "1.1.1.1:1000"
+	req.Header.Set("X-Tenant", "acme")
+	assert.Equal(t, "ip:1.1.1.1|acme", key(req))
+
+	req.Header.Set("X-User", "ada")
+	assert.Equal(t, "user:ada|acme", key(req))
+}
+
+type failingStore struct{}
+
+func (failingStore) Update(
+	context.Context, string, time.Duration, func([]byte) ([]byte, error),
+) error {
+	return errors.New("store unavailable")
+}
+
+func TestUnavailableStoreFailsOpen(t *testing.T) {
+	var reported []error
+	r := router.New().OnError(func(req *http.Request, err error) {
+		reported = append(reported, err)
+	})
+	r.Get("/", func() string { return "ok" }).Middleware(throttle.TokenBucket(throttle.Config{
+		Limit:  1,
+		Period: time.Minute,
+		Store:  failingStore{},
+	}))
+
+	assert.Equal(t, http.StatusOK, serve(r, "1.1.1.1:1000").Code)
+	assert.Equal(t, http.StatusOK, serve(r, "1.1.1.1:1000").Code)
+
+	if assert.Len(t, reported, 2) {
+		assert.EqualError(t, reported[0], "throttle: store unavailable")
+	}
+}
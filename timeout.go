@@ -0,0 +1,119 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// timeoutHandler returns a handler that serves requests with next, giving up once
+// d has passed. The request's context is cancelled at the deadline, and the
+// response is written by onTimeout, or is a 503 if onTimeout is nil.
+//
+// The response from next is buffered until it finishes, so that a response can
+// still be sent if the deadline passes while it is being written.
+func timeoutHandler(next http.Handler, d time.Duration, onTimeout http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{header: http.Header{}}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				v := recover()
+				switch {
+				case v == nil:
+				case v == http.ErrAbortHandler:
+					panicked <- v
+				default:
+					panicked <- handlerPanic{value: v, stack: debug.Stack()}
+				}
+			}()
+
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case v := <-panicked:
+			panic(v)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			for k, v := range tw.header {
+				w.Header()[k] = v
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.timedOut = true
+			if onTimeout != nil {
+				onTimeout.ServeHTTP(w, r)
+				return
+			}
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("503 service unavailable"))
+		}
+	})
+}
+
+// handlerPanic carries a panic from the goroutine a handler with a timeout runs
+// in to the goroutine serving the request, along with the stack trace of where
+// the handler panicked.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// timeoutWriter buffers the response from a handler with a timeout. Writes made
+// after the timeout has passed fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut || w.status != 0 {
+		return
+	}
+	w.status = code
+}
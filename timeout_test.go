@@ -0,0 +1,161 @@
+package router_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	cancelled := make(chan bool, 1)
+	r := router.New()
+	r.Get("slow", func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			cancelled <- true
+		case <-time.After(time.Second):
+			cancelled <- false
+		}
+		w.Write([]byte("too late"))
+	}).Timeout(10 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "503 service unavailable", rec.Body.String())
+	assert.True(t, <-cancelled)
+}
+
+func TestTimeoutPassesThroughFastResponses(t *testing.T) {
+	r := router.New()
+	r.Group(
+		router.Get("fast", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Fast", "yes")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("done"))
+		}),
+	).Timeout(time.Second)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "yes", rec.Header().Get("X-Fast"))
+	assert.Equal(t, "done", rec.Body.String())
+}
+
+func TestGroupTimeoutWithCustomResponse(t *testing.T) {
+	r := router.New().OnTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write([]byte("try again later"))
+	}))
+	r.Group(
+		router.Get("slow", func(req *http.Request) string {
+			<-req.Context().Done()
+			return ""
+		}),
+		router.Get("slower", func(req *http.Request) string {
+			<-req.Context().Done()
+			return ""
+		}).Timeout(20*time.Millisecond),
+	).Timeout(10 * time.Millisecond)
+
+	for _, path := range []string{"/slow", "/slower"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code, path)
+		assert.Equal(t, "try again later", rec.Body.String(), path)
+	}
+}
+
+func TestTimeoutResponsePassesThroughRouterMiddleware(t *testing.T) {
+	var status int
+	r := router.New().Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Frame-Options", "DENY")
+			rw := router.WrapResponseWriter(w)
+			next.ServeHTTP(rw, req)
+			status = rw.Status()
+		})
+	})
+	r.Get("slow", func(req *http.Request) string {
+		<-req.Context().Done()
+		return ""
+	}).Timeout(10 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestTimeoutPanicsAreRecovered(t *testing.T) {
+	var reported error
+	r := router.New().OnError(func(req *http.Request, err error) {
+		reported = err
+	})
+	r.Get("/", func() string {
+		panic("boom")
+	}).Timeout(time.Second)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var panicErr router.PanicError
+	if assert.True(t, errors.As(reported, &panicErr)) {
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.Contains(t, string(panicErr.Stack), "timeout_test.go")
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	r := router.New().MaxBodySize(8)
+	r.Post("form", func(req *http.Request) string {
+		return req.Form.Get("name")
+	})
+	r.Post("upload", func(req *http.Request) string {
+		return req.Form.Get("name")
+	}).MaxBodySize(1024)
+	r.Post("json", func(req *http.Request, input *struct {
+		Name string `json:"name"`
+	}) (interface{}, error) {
+		return input, nil
+	})
+
+	post := func(path string, contentType string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	form := "application/x-www-form-urlencoded"
+
+	rec := post("/form", form, "name=bob")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bob", rec.Body.String())
+
+	rec = post("/form", form, "name=robert")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "413 request entity too large", rec.Body.String())
+
+	rec = post("/upload", form, "name=robert")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "robert", rec.Body.String())
+
+	rec = post("/json", "application/json", `{"name": "robert"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
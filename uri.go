@@ -5,9 +5,9 @@ import (
 )
 
 // URI is a Validator that determines whether a given Route definition matches
-// the incoming request URI.
+// the incoming request path. The query string is not considered.
 type URI struct{}
 
 func (URI) Matches(route *Route, req *http.Request) bool {
-	return route.Regex().MatchString(req.RequestURI)
+	return route.Regex().MatchString(req.URL.Path)
 }
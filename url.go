@@ -0,0 +1,117 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// URL generates a URL for the route by substituting the given parameters into
+// its pattern. Optional parameters may be left out, and parameters that don't
+// appear in the pattern are added to the query string. An error is returned
+// if a required parameter is missing or a value doesn't match its pattern. If
+// the router reads versions from the path, the URL includes the route's version.
+func (r *Route) URL(params map[string]string) (string, error) {
+	// Work out the last optional segment that has a value. Any optional
+	// segments before it must be included in the path, falling back to their
+	// defaults, while any after it are left out.
+	last := -1
+	for i, s := range r.segments {
+		if s.optional() {
+			if _, ok := params[s.only().name]; ok {
+				last = i
+			}
+		}
+	}
+
+	used := map[string]bool{}
+
+	var b strings.Builder
+	for i, s := range r.segments[1:] {
+		if i == r.versionAt {
+			b.WriteString(r.versionPrefix)
+		}
+
+		if s.optional() && i+1 > last {
+			used[s.only().name] = true
+			continue
+		}
+
+		b.WriteString("/")
+		for _, pc := range s.pieces {
+			if pc.param == nil {
+				b.WriteString(pc.literal)
+				continue
+			}
+
+			v, err := paramValue(pc.param, params)
+			if err != nil {
+				return "", fmt.Errorf("router: cannot generate URL for %s: %s", r.pattern, err)
+			}
+
+			used[pc.param.name] = true
+			b.WriteString(escapeParam(pc.param, v))
+		}
+	}
+
+	if r.versionAt >= len(r.segments)-1 {
+		b.WriteString(r.versionPrefix)
+	}
+
+	path := b.String()
+	if path == "" {
+		path = "/"
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		if !used[k] {
+			query.Set(k, v)
+		}
+	}
+
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return path, nil
+}
+
+// paramValue looks up the value of the parameter `p` and checks it against the
+// parameter's pattern.
+func paramValue(p *param, params map[string]string) (string, error) {
+	v, ok := params[p.name]
+	if !ok {
+		if !p.optional || p.def == "" {
+			return "", fmt.Errorf("missing parameter %q", p.name)
+		}
+		v = p.def
+	}
+
+	rx, err := regexp.Compile("^(?:" + p.expr() + ")$")
+	if err != nil {
+		return "", err
+	}
+
+	if !rx.MatchString(v) {
+		return "", fmt.Errorf("parameter %q value %q does not match %s", p.name, v, p.expr())
+	}
+
+	return v, nil
+}
+
+// escapeParam escapes a parameter value for use in a path. Catch-all values
+// keep their `/` separators.
+func escapeParam(p *param, v string) string {
+	if !p.catchAll {
+		return url.PathEscape(v)
+	}
+
+	parts := strings.Split(v, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,275 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Rule checks a single field value against a validation rule. `arg` is the
+// text after the `=` in the rule's tag, such as `3` in `min=3`. The error's
+// message is reported to the client as the problem with the field.
+type Rule func(value interface{}, arg string) error
+
+// Validatable is implemented by structs that validate themselves. Validate is
+// called by ValidateStruct once the struct's tag rules have passed.
+type Validatable interface {
+	Validate() error
+}
+
+// ValidationError is returned by ValidateStruct when a struct fails validation.
+// It is an HTTPError with a 422 status, and Fields holds the problems with each
+// field.
+type ValidationError struct {
+	Fields map[string][]string
+}
+
+func (e ValidationError) Error() string {
+	return "validation failed: " + describeFields(e.Fields)
+}
+
+func (e ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// FieldErrors returns the problems with each field.
+func (e ValidationError) FieldErrors() map[string][]string {
+	return e.Fields
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{
+		"required": required,
+		"min":      minimum,
+		"max":      maximum,
+		"len":      length,
+		"email":    email,
+		"oneof":    oneOf,
+	}
+)
+
+// RegisterRule adds a rule that can be used in `validate` struct tags, replacing
+// any existing rule with the same name.
+func RegisterRule(name string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	rules[name] = rule
+}
+
+// ValidateStruct checks the fields of the struct `v` against the rules in their
+// `validate` tags, such as `validate:"required,min=3"`. Rules other than
+// `required` are skipped for fields with their zero value. Problems are keyed
+// by the name in the field's binding tag, or by the field name if it has none.
+func ValidateStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("router: cannot validate nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("router: ValidateStruct expects a struct, got %T", v)
+	}
+
+	var fields map[string][]string
+	if err := validateStruct(rv, &fields); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		return ValidationError{Fields: fields}
+	}
+
+	if validatable, ok := v.(Validatable); ok {
+		return selfValidate(validatable)
+	}
+
+	return nil
+}
+
+func validateStruct(v reflect.Value, fields *map[string][]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := validateStruct(v.Field(i), fields); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+
+		value := v.Field(i)
+		for _, spec := range strings.Split(tag, ",") {
+			name, arg := spec, ""
+			if j := strings.IndexByte(spec, '='); j >= 0 {
+				name, arg = spec[:j], spec[j+1:]
+			}
+
+			rulesMu.RLock()
+			rule, ok := rules[name]
+			rulesMu.RUnlock()
+			if !ok {
+				return fmt.Errorf("router: unknown validation rule %q on %s.%s", name, t.Name(), field.Name)
+			}
+
+			if name != "required" && value.IsZero() {
+				continue
+			}
+
+			if err := rule(value.Interface(), arg); err != nil {
+				if *fields == nil {
+					*fields = map[string][]string{}
+				}
+				key := fieldName(field)
+				(*fields)[key] = append((*fields)[key], err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// selfValidate calls the struct's own Validate method. Errors that don't
+// describe individual fields are given a 422 status.
+func selfValidate(v Validatable) error {
+	err := v.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var fields fieldErrors
+	var httpErr HTTPError
+	if errors.As(err, &fields) || errors.As(err, &httpErr) {
+		return err
+	}
+
+	return StatusError{Code: http.StatusUnprocessableEntity, Err: err}
+}
+
+// fieldName returns the name a field is known by to the client.
+func fieldName(field reflect.StructField) string {
+	for _, source := range append(bindSources, "json") {
+		if name := strings.Split(field.Tag.Get(source), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// size returns the length of strings, slices and maps, or the value of numbers,
+// for comparison with the argument of a rule such as `min`.
+func size(value interface{}) (float64, bool) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// isCounted determines whether `min` and friends refer to a length rather than
+// the value itself.
+func isCounted(value interface{}) bool {
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+
+	return false
+}
+
+func compareSize(
+	value interface{}, arg string, ok func(n, limit float64) bool, counted string, plain string,
+) error {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return fmt.Errorf("has an invalid rule argument %q", arg)
+	}
+
+	n, sized := size(value)
+	if !sized {
+		return errors.New("cannot be measured")
+	}
+
+	if ok(n, limit) {
+		return nil
+	}
+
+	if isCounted(value) {
+		return fmt.Errorf(counted, arg)
+	}
+
+	return fmt.Errorf(plain, arg)
+}
+
+func required(value interface{}, _ string) error {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.IsZero() {
+		return errors.New("is required")
+	}
+
+	return nil
+}
+
+func minimum(value interface{}, arg string) error {
+	return compareSize(value, arg, func(n, limit float64) bool { return n >= limit },
+		"must be at least %s characters or items long", "must be at least %s")
+}
+
+func maximum(value interface{}, arg string) error {
+	return compareSize(value, arg, func(n, limit float64) bool { return n <= limit },
+		"must be at most %s characters or items long", "must be at most %s")
+}
+
+func length(value interface{}, arg string) error {
+	return compareSize(value, arg, func(n, limit float64) bool { return n == limit },
+		"must be exactly %s characters or items long", "must be exactly %s")
+}
+
+func email(value interface{}, _ string) error {
+	s := fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)).Interface())
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return errors.New("must be a valid email address")
+	}
+
+	return nil
+}
+
+func oneOf(value interface{}, arg string) error {
+	s := fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)).Interface())
+	options := strings.Fields(arg)
+	for _, option := range options {
+		if s == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of: %s", strings.Join(options, ", "))
+}
@@ -0,0 +1,136 @@
+package router_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+type signup struct {
+	Name     string   `json:"name" validate:"required,min=3"`
+	Email    string   `json:"email" validate:"required,email"`
+	Age      int      `json:"age" validate:"min=18,max=130"`
+	Plan     string   `json:"plan" validate:"oneof=free pro"`
+	Password string   `json:"password" validate:"required"`
+	Confirm  string   `json:"confirm"`
+	Tags     []string `json:"tags" validate:"max=2"`
+}
+
+func (s signup) Validate() error {
+	if s.Password != s.Confirm {
+		return errors.New("passwords do not match")
+	}
+	return nil
+}
+
+func TestValidateStruct(t *testing.T) {
+	err := router.ValidateStruct(signup{
+		Name:  "Al",
+		Email: "not-an-email",
+		Age:   12,
+		Plan:  "enterprise",
+		Tags:  []string{"a", "b", "c"},
+	})
+
+	var validationErr router.ValidationError
+	if !assert.True(t, errors.As(err, &validationErr)) {
+		return
+	}
+
+	assert.Equal(t, map[string][]string{
+		"name":     {"must be at least 3 characters or items long"},
+		"email":    {"must be a valid email address"},
+		"age":      {"must be at least 18"},
+		"plan":     {"must be one of: free, pro"},
+		"password": {"is required"},
+		"tags":     {"must be at most 2 characters or items long"},
+	}, validationErr.Fields)
+}
+
+func TestValidateStructCallsValidate(t *testing.T) {
+	err := router.ValidateStruct(&signup{
+		Name:     "Ada",
+		Email:    "ada@example.com",
+		Password: "secret",
+		Confirm:  "different",
+	})
+
+	var httpErr router.HTTPError
+	if assert.True(t, errors.As(err, &httpErr)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, httpErr.StatusCode())
+		assert.Equal(t, "passwords do not match", httpErr.Error())
+	}
+}
+
+func TestCustomValidationRule(t *testing.T) {
+	router.RegisterRule("even", func(value interface{}, _ string) error {
+		if value.(int)%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+
+	type input struct {
+		Count int `query:"count" validate:"even"`
+	}
+
+	assert.NoError(t, router.ValidateStruct(input{Count: 2}))
+	err := router.ValidateStruct(input{Count: 3})
+	assert.EqualError(t, err, "validation failed: count: must be even")
+}
+
+func TestUnknownValidationRule(t *testing.T) {
+	type input struct {
+		Name string `validate:"shiny"`
+	}
+
+	err := router.ValidateStruct(input{})
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, new(router.ValidationError)))
+}
+
+func TestHandlersReceiveValidatedStructs(t *testing.T) {
+	r := router.New()
+	r.Post("signup", func(req *http.Request, s *signup) (interface{}, error) {
+		return map[string]string{"welcome": s.Name, "path": req.URL.Path}, nil
+	})
+	r.Post("plain", func(s signup) (interface{}, error) {
+		return s.Email, nil
+	})
+
+	post := func(path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	valid := `{"name":"Ada","email":"ada@example.com","password":"x","confirm":"x"}`
+
+	rec := post("/signup", valid)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"welcome":"Ada","path":"/signup"}`, rec.Body.String())
+
+	rec = post("/plain", valid)
+	assert.JSONEq(t, `"ada@example.com"`, rec.Body.String())
+
+	rec = post("/signup", `{"name":"Ada","email":"nope","password":"x","confirm":"x"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"error": "validation failed: email: must be a valid email address",
+		"fields": {"email": ["must be a valid email address"]}
+	}`, rec.Body.String())
+
+	rec = post("/signup", `{"age":"old"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid request: body: age must be an integer",
+		"fields": {"body": ["age must be an integer"]}
+	}`, rec.Body.String())
+}
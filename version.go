@@ -0,0 +1,216 @@
+package router
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionStrategy determines which version of an API a request is for.
+type VersionStrategy interface {
+	// Version returns the version requested by r, or an empty string if r
+	// doesn't request a particular version. The returned request is the one
+	// matched against versioned routes, which allows a strategy to remove the
+	// version from the request path.
+	Version(r *http.Request) (string, *http.Request)
+}
+
+// Versioning sets the strategy the router uses to select between versions of
+// routes defined with Version. Requests that don't specify a version are
+// treated as requesting `def`. If def is empty, they only match routes that
+// are not versioned.
+func (router *Router) Versioning(strategy VersionStrategy, def string) *Router {
+	router.update(func() {
+		router.versioning = strategy
+		router.defaultVersion = def
+	})
+	return router
+}
+
+// Version creates a new route Group for the given API version. Routes added to
+// the group in `fn` are only matched by requests for that version, or for a
+// later version that doesn't define a matching route of its own.
+func (router *Router) Version(version string, fn func(g *Group)) *Group {
+	g := router.Group()
+	router.update(func() {
+		g.version = version
+	})
+
+	fn(g)
+
+	return g
+}
+
+// Version is a Validator that determines whether a given Route definition is
+// available in the API version requested by the incoming request. Routes that
+// are not versioned are available in every version.
+type Version struct{}
+
+func (Version) Matches(route *Route, req *http.Request) bool {
+	if route.version == "" {
+		return true
+	}
+
+	requested, _ := req.Context().Value(versionKey).(string)
+	if requested == "" {
+		return false
+	}
+
+	return compareVersions(route.version, requested) <= 0
+}
+
+// withVersion returns a shallow copy of the request with the requested version
+// attached to its context.
+func withVersion(r *http.Request, version string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), versionKey, version))
+}
+
+// findVersionedRoute finds the route for a request when the table contains
+// versioned routes. Routes are matched in order of precedence as usual, and of
+// the matching routes that are equally specific, the one with the latest
+// version wins. A request falls back to the closest older version when its own
+// version doesn't define a matching route.
+func (router *Router) findVersionedRoute(t *table, r *http.Request) (*Route, *http.Request, bool) {
+	version, vr := "", r
+	if t.versioning != nil {
+		version, vr = t.versioning.Version(r)
+	}
+	if version == "" {
+		version = t.defaultVersion
+	}
+
+	r, vr = withVersion(r, version), withVersion(vr, version)
+
+	var best *Route
+	var bestReq *http.Request
+	for _, route := range t.routes {
+		if best != nil && compare(route, best) != 0 {
+			break
+		}
+
+		req := r
+		if route.version != "" {
+			req = vr
+		}
+
+		if !route.matches(t, req) {
+			continue
+		}
+
+		if best == nil || compareVersions(route.version, best.version) > 0 {
+			best, bestReq = route, req
+		}
+	}
+
+	return best, bestReq, best != nil
+}
+
+var versionNumber = regexp.MustCompile(`^[vV]?[0-9]+(\.[0-9]+)*$`)
+
+// compareVersions compares two version names, returning a negative number if
+// `a` is older than `b`. Versions such as `v2` and `1.10` are compared by
+// their numeric parts, and an empty version is older than any other.
+func compareVersions(a string, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	case !versionNumber.MatchString(a) || !versionNumber.MatchString(b):
+		return strings.Compare(a, b)
+	}
+
+	as := strings.Split(strings.TrimLeft(a, "vV"), ".")
+	bs := strings.Split(strings.TrimLeft(b, "vV"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+
+	return 0
+}
+
+type pathVersion struct{}
+
+// PathVersion is a VersionStrategy that reads the version from the first
+// segment of the request path, such as `/v2/users`. The version is removed from
+// the path before it is matched against versioned routes.
+func PathVersion() VersionStrategy {
+	return pathVersion{}
+}
+
+func (pathVersion) Version(r *http.Request) (string, *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	first, rest := p, ""
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		first, rest = p[:i], p[i:]
+	}
+
+	if !versionNumber.MatchString(first) {
+		return "", r
+	}
+
+	if rest == "" {
+		rest = "/"
+	}
+
+	return first, withPath(r, rest)
+}
+
+type headerVersion struct {
+	header string
+}
+
+// HeaderVersion is a VersionStrategy that reads the version from the given
+// request header, such as `X-API-Version: v2`.
+func HeaderVersion(header string) VersionStrategy {
+	return headerVersion{header: header}
+}
+
+func (s headerVersion) Version(r *http.Request) (string, *http.Request) {
+	return strings.TrimSpace(r.Header.Get(s.header)), r
+}
+
+type mediaTypeVersion struct {
+	prefix string
+}
+
+// MediaTypeVersion is a VersionStrategy that reads the version from a vendor
+// media type in the request's Accept header. For the vendor `acme`, a request
+// accepting `application/vnd.acme.v2+json` is for version `v2`.
+func MediaTypeVersion(vendor string) VersionStrategy {
+	return mediaTypeVersion{prefix: "application/vnd." + vendor + "."}
+}
+
+func (s mediaTypeVersion) Version(r *http.Request) (string, *http.Request) {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || !strings.HasPrefix(mediaType, s.prefix) {
+				continue
+			}
+
+			version := strings.TrimPrefix(mediaType, s.prefix)
+			if i := strings.IndexByte(version, '+'); i >= 0 {
+				version = version[:i]
+			}
+
+			return version, r
+		}
+	}
+
+	return "", r
+}
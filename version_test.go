@@ -0,0 +1,156 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostalt/router"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersioning(t *testing.T) {
+	type testcase struct {
+		strategy router.VersionStrategy
+		def      string
+		path     string
+		header   []string
+		expected string
+	}
+
+	cases := map[string]testcase{
+		"path selects version": {
+			strategy: router.PathVersion(),
+			path:     "/v2/users",
+			expected: "v2 users",
+		},
+		"path falls back to older version": {
+			strategy: router.PathVersion(),
+			path:     "/v2/posts",
+			expected: "v1 posts",
+		},
+		"versions are compared numerically": {
+			strategy: router.PathVersion(),
+			path:     "/v11/users",
+			expected: "v10 users",
+		},
+		"unversioned routes match without a version": {
+			strategy: router.PathVersion(),
+			path:     "/health",
+			expected: "ok",
+		},
+		"versioned routes need a version": {
+			strategy: router.PathVersion(),
+			path:     "/users",
+			expected: "404 not found",
+		},
+		"default version is used without a version": {
+			strategy: router.PathVersion(),
+			def:      "v1",
+			path:     "/users",
+			expected: "v1 users",
+		},
+		"older versions than any route are not found": {
+			strategy: router.PathVersion(),
+			path:     "/v0/users",
+			expected: "404 not found",
+		},
+		"header selects version": {
+			strategy: router.HeaderVersion("X-API-Version"),
+			path:     "/users",
+			header:   []string{"X-API-Version", "v2"},
+			expected: "v2 users",
+		},
+		"media type selects version": {
+			strategy: router.MediaTypeVersion("acme"),
+			path:     "/posts",
+			header:   []string{"Accept", "text/html, application/vnd.acme.v2+json; q=0.9"},
+			expected: "v1 posts",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := router.New().Versioning(tc.strategy, tc.def)
+			r.Get("health", func() string { return "ok" })
+			r.Version("v1", func(g *router.Group) {
+				g.Add(
+					router.Get("users", func() string { return "v1 users" }),
+					router.Get("posts", func() string { return "v1 posts" }),
+				)
+			})
+			r.Version("v2", func(g *router.Group) {
+				g.Add(router.Get("users", func() string { return "v2 users" }))
+			})
+			r.Version("v10", func(g *router.Group) {
+				g.Add(router.Get("users", func() string { return "v10 users" }))
+			})
+
+			rec := record(r, httptest.NewRequest(http.MethodGet, tc.path, nil), tc.header...)
+			assert.Equal(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestVersionedRoutesDontConflict(t *testing.T) {
+	r := router.New().Versioning(router.PathVersion(), "")
+	r.Get("users", func() string { return "users" })
+	r.Version("v1", func(g *router.Group) {
+		g.Add(router.Get("users", func() string { return "v1 users" }))
+	})
+	r.Version("v2", func(g *router.Group) {
+		g.Add(router.Get("users", func() string { return "v2 users" }))
+	})
+
+	assert.NoError(t, r.Validate())
+}
+
+func TestSpecificRoutesWinOverVersions(t *testing.T) {
+	r := router.New().Versioning(router.HeaderVersion("X-API-Version"), "")
+	r.Get("users/me", func() string { return "me" })
+	r.Version("v1", func(g *router.Group) {
+		g.Add(router.Get("users/{id}", func() string { return "v1 id" }))
+	})
+	r.Version("v2", func(g *router.Group) {
+		g.Add(router.Get("users/{id}", func() string { return "v2 id" }))
+	})
+
+	for path, expected := range map[string]string{"/users/me": "me", "/users/1": "v2 id"} {
+		rec := record(r, httptest.NewRequest(http.MethodGet, path, nil), "X-API-Version", "v2")
+		assert.Equal(t, expected, rec.Body.String(), path)
+	}
+}
+
+func TestURLsForPathVersionedRoutes(t *testing.T) {
+	r := router.New().Versioning(router.PathVersion(), "").SigningKeys([]byte("secret"))
+	r.Version("v2", func(g *router.Group) {
+		g.Add(
+			router.Get("download/{id}", func(req *http.Request) string {
+				return "download " + router.Param(req, "id")
+			}).Name("download").Middleware(router.ValidateSignature),
+			router.Get("/", func() string { return "v2" }).Name("home"),
+		)
+	})
+
+	u, err := r.URL("download", map[string]string{"id": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/download/1", u)
+
+	u, err = r.URL("home", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", record(r, httptest.NewRequest(http.MethodGet, u, nil)).Body.String())
+
+	u, err = r.SignedURL("download", map[string]string{"id": "1"}, 0)
+	assert.NoError(t, err)
+	rec := record(r, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "download 1", rec.Body.String())
+
+	api := router.New().SigningKeys([]byte("secret"))
+	api.Mount("/api", r)
+	u, err = api.SignedURL("download", map[string]string{"id": "1"}, 0)
+	assert.NoError(t, err)
+	assert.Contains(t, u, "/api/v2/download/1?")
+	rec = record(api, httptest.NewRequest(http.MethodGet, u, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}